@@ -1,32 +1,134 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+
 	"github.com/bagasadiii/buy-n-con/handler"
-	mw "github.com/bagasadiii/buy-n-con/internal/middleware"
-	router "github.com/julienschmidt/httprouter"
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/config"
+	"github.com/bagasadiii/buy-n-con/internal/metrics"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/openapi"
+	"github.com/rs/cors"
 )
+
 type Routes struct {
-	User handler.UserHandlerImpl
-	Item handler.ItemHandlerImpl
-	Post handler.PostHandlerImpl
+	User         handler.UserHandlerImpl
+	Item         handler.ItemHandlerImpl
+	Post         handler.PostHandlerImpl
+	Comment      handler.CommentHandlerImpl
+	Reaction     handler.ReactionHandlerImpl
+	Follow       handler.FollowHandlerImpl
+	Feed         handler.FeedHandlerImpl
+	Tag          handler.TagHandlerImpl
+	Message      handler.MessageHandlerImpl
+	Realtime     handler.RealtimeHandlerImpl
+	Notification handler.NotificationHandlerImpl
+	Events       handler.EventsHandlerImpl
+	Webhook      handler.WebhookHandlerImpl
+	Health       handler.HealthHandlerImpl
+}
+
+// SetupRouter registers every route, guarding private ones with mw, and wraps
+// the router in the CORS policy from cfg. It fails when the OpenAPI document
+// cannot be built. Routes and operations that disagree are only logged; the
+// tests keep them in step.
+func SetupRouter(route *Routes, mw *middleware.TokenAuth, cfg *config.Config) (http.Handler, error) {
+	r, err := newRouter(route, mw)
+	if err != nil {
+		return nil, err
+	}
+	if err := openapi.Check(r.routes, operations); err != nil {
+		helper.Log(context.Background()).WithError(err).Warn("routes and OpenAPI operations disagree")
+	}
+	c := cors.New(cors.Options{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders:   []string{"Authorization", "Content-Type", "If-Match", "If-None-Match", "Last-Event-ID", "X-Request-ID"},
+		ExposedHeaders:   []string{"ETag", "X-Request-ID"},
+		AllowCredentials: true,
+		Debug:            cfg.Log.Level == "debug",
+	})
+	return c.Handler(r), nil
 }
-func SetupRouter(route *Routes)*router.Router{
-	r := router.New()
+
+func newRouter(route *Routes, mw *middleware.TokenAuth) (*instrumentedRouter, error) {
+	spec, err := openapi.Build(apiInfo, operations)
+	if err != nil {
+		return nil, fmt.Errorf("build OpenAPI document: %w", err)
+	}
+	r := newInstrumentedRouter()
+	r.Handler(http.MethodGet, "/metrics", metrics.Handler())
+	r.GET(specPath, adapt(openapi.Handler(spec)))
+	r.GET(docsPath, adapt(openapi.DocsHandler(apiInfo.Title, specPath)))
+	r.GET("/healthz", route.Health.Healthz)
+	r.GET("/readyz", route.Health.Readyz)
+	r.GET("/version", route.Health.Version)
 
 	r.POST("/api/register", route.User.Register)
 	r.POST("/api/login", route.User.Login)
 	r.GET("/api/u/:username", route.User.GetUserByUsername)
+	r.POST("/api/u/:username/follow", mw.Auth(route.Follow.Follow))
+	r.DELETE("/api/u/:username/follow", mw.Auth(route.Follow.Unfollow))
+	r.GET("/api/u/:username/followers", route.Follow.GetFollowers)
+	r.GET("/api/u/:username/following", route.Follow.GetFollowing)
+	r.POST("/api/u/:username/block", mw.Auth(route.Message.BlockUser))
+	r.DELETE("/api/u/:username/block", mw.Auth(route.Message.UnblockUser))
+	r.GET("/api/feed", mw.Auth(route.Feed.GetFeed))
+	r.GET("/api/tags/:tag/posts", mw.OptionalAuth(route.Tag.GetPostsByTag))
+	r.GET("/api/trending/tags", route.Tag.GetTrendingTags)
 
 	r.POST("/api/u/:username/items", mw.Auth(route.Item.CreateItem))
-	r.GET("/api/u/:username/items/:item_id", route.Item.GetItemByID)
-	r.GET("/api/u/:username/items", route.Item.GetAllItems)
+	r.GET("/api/u/:username/items/:item_id", mw.OptionalAuth(route.Item.GetItemByID))
+	r.GET("/api/u/:username/items", mw.OptionalAuth(route.Item.GetAllItems))
 	r.PATCH("/api/u/:username/items/:item_id", mw.Auth(route.Item.UpdateItem))
 	r.DELETE("/api/u/:username/items/:item_id", mw.Auth(route.Item.DeleteItem))
+	r.PATCH("/api/u/:username/items/:item_id/status", mw.Auth(route.Item.UpdateItemStatus))
+	r.GET("/api/u/:username/items/:item_id/history", mw.OptionalAuth(route.Item.GetItemHistory))
 
 	r.POST("/api/u/:username/post", mw.Auth(route.Post.CreatePost))
-	r.GET("/api/u/:username/post/:post_id", route.Post.GetPostByID)
-	r.GET("/api/u/:username/post", route.Post.GetAllPosts)
+	r.GET("/api/u/:username/post/:post_id", mw.OptionalAuth(route.Post.GetPostByID))
+	r.GET("/api/u/:username/post", mw.OptionalAuth(route.Post.GetAllPosts))
 	r.PATCH("/api/u/:username/post/:post_id", mw.Auth(route.Post.UpdatePost))
 	r.DELETE("/api/u/:username/post/:post_id", mw.Auth(route.Post.DeletePost))
-	return r
-}
\ No newline at end of file
+
+	r.POST("/api/u/:username/post/:post_id/comments", mw.Auth(route.Comment.CreateComment))
+	r.GET("/api/u/:username/post/:post_id/comments", route.Comment.GetComments)
+	r.GET("/api/u/:username/post/:post_id/comments/:comment_id/replies", route.Comment.GetReplies)
+	r.PATCH("/api/u/:username/post/:post_id/comments/:comment_id", mw.Auth(route.Comment.UpdateComment))
+	r.DELETE("/api/u/:username/post/:post_id/comments/:comment_id", mw.Auth(route.Comment.DeleteComment))
+
+	r.POST("/api/u/:username/post/:post_id/reactions", mw.Auth(route.Reaction.ReactToPost))
+	r.POST("/api/u/:username/items/:item_id/reactions", mw.Auth(route.Reaction.ReactToItem))
+
+	r.POST("/api/conversations", mw.Auth(route.Message.StartConversation))
+	r.GET("/api/conversations", mw.Auth(route.Message.GetConversations))
+	r.GET("/api/conversations/:conversation_id/messages", mw.Auth(route.Message.GetMessages))
+	r.POST("/api/conversations/:conversation_id/messages", mw.Auth(route.Message.SendMessage))
+	r.POST("/api/conversations/:conversation_id/read", mw.Auth(route.Message.MarkRead))
+
+	r.GET("/api/notifications", mw.Auth(route.Notification.GetNotifications))
+	r.POST("/api/notifications/read-all", mw.Auth(route.Notification.MarkAllRead))
+	r.POST("/api/notifications/read/:notification_id", mw.Auth(route.Notification.MarkRead))
+	r.GET("/api/notifications/preferences", mw.Auth(route.Notification.GetPreferences))
+	r.PATCH("/api/notifications/preferences", mw.Auth(route.Notification.UpdatePreferences))
+
+	r.POST("/api/webhooks", mw.Auth(route.Webhook.CreateWebhook))
+	r.GET("/api/webhooks", mw.Auth(route.Webhook.GetWebhooks))
+	r.DELETE("/api/webhooks/:webhook_id", mw.Auth(route.Webhook.DeleteWebhook))
+	r.GET("/api/webhooks/:webhook_id/deliveries", mw.Auth(route.Webhook.GetDeliveries))
+	r.POST("/api/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", mw.Auth(route.Webhook.Redeliver))
+
+	r.POST("/api/stream-tickets", mw.Auth(route.Realtime.IssueTicket))
+	r.GET("/api/ws", route.Realtime.Connect)
+	r.GET("/api/events", route.Events.Stream)
+
+	r.GET("/api/u/:username/trash/items", mw.Auth(route.Item.GetDeletedItems))
+	r.POST("/api/u/:username/trash/items/:item_id/restore", mw.Auth(route.Item.RestoreItem))
+	r.GET("/api/u/:username/trash/post", mw.Auth(route.Post.GetDeletedPosts))
+	r.POST("/api/u/:username/trash/post/:post_id/restore", mw.Auth(route.Post.RestorePost))
+
+	return r, nil
+}
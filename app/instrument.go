@@ -0,0 +1,164 @@
+package app
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/metrics"
+	"github.com/bagasadiii/buy-n-con/internal/telemetry"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	maxRequestIDLen = 128
+)
+
+// instrumentedRouter registers every route through instrument, so a new
+// route is traced and measured under its pattern without further wiring.
+// It also remembers each route, for checking them against the API docs.
+type instrumentedRouter struct {
+	*router.Router
+	routes []string
+}
+
+func newInstrumentedRouter() *instrumentedRouter {
+	r := &instrumentedRouter{Router: router.New()}
+	unmatched := func(status int) http.Handler {
+		handle := instrument("unmatched", func(w http.ResponseWriter, req *http.Request, p router.Params) {
+			helper.WriteProblem(w, req, helper.NewProblem(status, ""))
+		})
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { handle(w, req, nil) })
+	}
+	r.NotFound = unmatched(http.StatusNotFound)
+	r.MethodNotAllowed = unmatched(http.StatusMethodNotAllowed)
+	return r
+}
+
+func (r *instrumentedRouter) Handle(method, path string, handle router.Handle) {
+	r.routes = append(r.routes, method+" "+path)
+	r.Router.Handle(method, path, instrument(path, handle))
+}
+
+// Handler registers h as is, for endpoints such as /metrics that should not
+// measure themselves.
+func (r *instrumentedRouter) Handler(method, path string, h http.Handler) {
+	r.routes = append(r.routes, method+" "+path)
+	r.Router.Handler(method, path, h)
+}
+
+// adapt turns h into to a route that takes no parameters.
+func adapt(h http.Handler) router.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p router.Params) {
+		h.ServeHTTP(w, r)
+	}
+}
+
+func (r *instrumentedRouter) GET(path string, handle router.Handle) {
+	r.Handle(http.MethodGet, path, handle)
+}
+
+func (r *instrumentedRouter) POST(path string, handle router.Handle) {
+	r.Handle(http.MethodPost, path, handle)
+}
+
+func (r *instrumentedRouter) PATCH(path string, handle router.Handle) {
+	r.Handle(http.MethodPatch, path, handle)
+}
+
+func (r *instrumentedRouter) PUT(path string, handle router.Handle) {
+	r.Handle(http.MethodPut, path, handle)
+}
+
+func (r *instrumentedRouter) DELETE(path string, handle router.Handle) {
+	r.Handle(http.MethodDelete, path, handle)
+}
+
+// instrument gives every request to next an ID, a server span and an access
+// log line, and records its status and latency under route.
+func instrument(route string, next router.Handle) router.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p router.Params) {
+		start := time.Now()
+		requestID := requestID(r)
+		w.Header().Set(requestIDHeader, requestID)
+		r = r.WithContext(helper.WithRequestLogger(r.Context(), requestID))
+		ctx, span := telemetry.StartHTTPSpan(r, route)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r.WithContext(ctx), p)
+		elapsed := time.Since(start)
+		telemetry.EndHTTPSpan(span, rec.status)
+		metrics.ObserveHTTP(r.Method, route, rec.status, elapsed)
+		helper.LogAccess(ctx, helper.AccessLog{
+			Method:     r.Method,
+			Route:      route,
+			Path:       r.URL.Path,
+			Status:     rec.status,
+			DurationMS: float64(elapsed.Microseconds()) / 1000,
+			Bytes:      rec.bytes,
+		})
+	}
+}
+
+// requestID keeps the caller's X-Request-ID so a request can be followed
+// across services, unless it is missing or unsafe to log; then it makes one.
+func requestID(r *http.Request) string {
+	id := r.Header.Get(requestIDHeader)
+	if id == "" || len(id) > maxRequestIDLen {
+		return uuid.NewString()
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' || c == ':') {
+			return uuid.NewString()
+		}
+	}
+	return id
+}
+
+// statusRecorder remembers the status code and body size written. It passes
+// Flush and Hijack through, which SSE and WebSockets need. An upgraded
+// connection is counted as 101.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.status = http.StatusSwitchingProtocols
+		r.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/bagasadiii/buy-n-con/internal/buildinfo"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/openapi"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+)
+
+const (
+	specPath = "/openapi.json"
+	docsPath = "/docs"
+)
+
+var apiInfo = openapi.Info{
+	Title:       "Buy-n-Con API",
+	Version:     buildinfo.Get().Commit,
+	Description: "Marketplace and social API: users list items, share posts and message each other.",
+}
+
+// Shared parameters of the operations below.
+var (
+	limit  = openapi.Param{Name: "limit", Value: 0, Description: "Page size."}
+	offset = openapi.Param{Name: "offset", Value: 0, Description: "Number of entries to skip."}
+	paged  = pagedWith()
+	ticket = openapi.Param{Name: "ticket", Description: "Stream ticket from POST /api/stream-tickets, for clients that cannot set the Authorization header."}
+)
+
+func pagedWith(params ...openapi.Param) []openapi.Param {
+	return append([]openapi.Param{limit, offset}, params...)
+}
+
+const mergePatch = "application/merge-patch+json"
+
+// operations documents every route SetupRouter registers. The tests fail
+// when the two disagree, so a new route must be added here.
+var operations = []openapi.Operation{
+	{Method: http.MethodGet, Path: "/metrics", Tag: "Operations", Summary: "Prometheus metrics", Content: "text/plain"},
+	{Method: http.MethodGet, Path: "/healthz", Tag: "Operations", Summary: "Liveness probe"},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "Operations", Summary: "Readiness probe, with the result of each dependency check", Data: map[string]string{}},
+	{Method: http.MethodGet, Path: "/version", Tag: "Operations", Summary: "Build information", Data: buildinfo.Info{}},
+	{Method: http.MethodGet, Path: specPath, Tag: "Operations", Summary: "This OpenAPI document", Content: "application/json"},
+	{Method: http.MethodGet, Path: docsPath, Tag: "Operations", Summary: "API reference rendered with Redoc", Content: "text/html"},
+
+	{Method: http.MethodPost, Path: "/api/register", Tag: "Users", Summary: "Register a user", Body: model.RegisterInput{}, Status: http.StatusCreated, Data: model.User{}},
+	{Method: http.MethodPost, Path: "/api/login", Tag: "Users", Summary: "Log in and get a bearer token", Body: model.LoginInput{}, Data: middleware.UserValidation{}},
+	{Method: http.MethodGet, Path: "/api/u/:username", Tag: "Users", Summary: "Get a user's profile", Data: model.UserResponse{}},
+	{Method: http.MethodPost, Path: "/api/u/:username/follow", Tag: "Follows", Summary: "Follow a user", Auth: openapi.AuthRequired},
+	{Method: http.MethodDelete, Path: "/api/u/:username/follow", Tag: "Follows", Summary: "Unfollow a user", Auth: openapi.AuthRequired},
+	{Method: http.MethodGet, Path: "/api/u/:username/followers", Tag: "Follows", Summary: "List a user's followers", Query: paged, Data: model.FollowsPageRes{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/following", Tag: "Follows", Summary: "List the users a user follows", Query: paged, Data: model.FollowsPageRes{}},
+	{Method: http.MethodPost, Path: "/api/u/:username/block", Tag: "Messages", Summary: "Block a user from messaging you", Auth: openapi.AuthRequired},
+	{Method: http.MethodDelete, Path: "/api/u/:username/block", Tag: "Messages", Summary: "Unblock a user", Auth: openapi.AuthRequired},
+	{Method: http.MethodGet, Path: "/api/feed", Tag: "Feed", Summary: "Home feed of the users you follow", Auth: openapi.AuthRequired, Query: []openapi.Param{
+		limit,
+		{Name: "cursor", Description: "next_cursor of the previous page."},
+	}, Data: model.FeedRes{}},
+	{Method: http.MethodGet, Path: "/api/tags/:tag/posts", Tag: "Hashtags", Summary: "List posts with a hashtag", Auth: openapi.AuthOptional, Query: paged, Data: model.PostsPageRes{}},
+	{Method: http.MethodGet, Path: "/api/trending/tags", Tag: "Hashtags", Summary: "Most used hashtags", Query: []openapi.Param{
+		{Name: "window", Description: "How far back to count, as a Go duration such as 24h."},
+		limit,
+	}, Data: []model.TrendingTag{}},
+
+	{Method: http.MethodPost, Path: "/api/u/:username/items", Tag: "Items", Summary: "Create an item", Auth: openapi.AuthRequired, Body: model.CreateItemInput{}, Status: http.StatusCreated, Data: model.Item{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/items/:item_id", Tag: "Items", Summary: "Get an item", Auth: openapi.AuthOptional, Data: model.ItemResp{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/items", Tag: "Items", Summary: "List a user's items", Auth: openapi.AuthOptional, Query: pagedWith(
+		openapi.Param{Name: "status", Value: model.ItemStatus(""), Description: "Only items in this status. Statuses other than active, reserved and sold are visible to the owner only."},
+	), Data: model.ItemsPageRes{}},
+	{Method: http.MethodPatch, Path: "/api/u/:username/items/:item_id", Tag: "Items", Summary: "Update an item with a JSON merge patch", Auth: openapi.AuthRequired, Body: model.UpdateItemInput{}, BodyType: mergePatch, Data: model.ItemResp{}},
+	{Method: http.MethodDelete, Path: "/api/u/:username/items/:item_id", Tag: "Items", Summary: "Move an item to the trash", Auth: openapi.AuthRequired},
+	{Method: http.MethodPatch, Path: "/api/u/:username/items/:item_id/status", Tag: "Items", Summary: "Change an item's status", Auth: openapi.AuthRequired, Body: model.UpdateItemStatusInput{}, Data: model.ItemResp{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/items/:item_id/history", Tag: "Items", Summary: "List an item's revisions", Auth: openapi.AuthOptional, Query: paged, Data: model.ItemHistoryRes{}},
+
+	{Method: http.MethodPost, Path: "/api/u/:username/post", Tag: "Posts", Summary: "Create a post", Auth: openapi.AuthRequired, Body: model.PostInput{}, Status: http.StatusCreated, Data: model.Post{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/post/:post_id", Tag: "Posts", Summary: "Get a post", Auth: openapi.AuthOptional, Data: model.Post{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/post", Tag: "Posts", Summary: "List a user's posts", Auth: openapi.AuthOptional, Query: paged, Data: model.PostsPageRes{}},
+	{Method: http.MethodPatch, Path: "/api/u/:username/post/:post_id", Tag: "Posts", Summary: "Update a post with a JSON merge patch", Auth: openapi.AuthRequired, Body: model.UpdatePostInput{}, BodyType: mergePatch, Data: model.Post{}},
+	{Method: http.MethodDelete, Path: "/api/u/:username/post/:post_id", Tag: "Posts", Summary: "Move a post to the trash", Auth: openapi.AuthRequired},
+
+	{Method: http.MethodPost, Path: "/api/u/:username/post/:post_id/comments", Tag: "Comments", Summary: "Comment on a post, or reply to a comment", Auth: openapi.AuthRequired, Body: model.CommentInput{}, Status: http.StatusCreated, Data: model.Comment{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/post/:post_id/comments", Tag: "Comments", Summary: "List a post's top-level comments", Query: paged, Data: model.CommentsPageRes{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/post/:post_id/comments/:comment_id/replies", Tag: "Comments", Summary: "List the replies to a comment", Query: paged, Data: model.CommentsPageRes{}},
+	{Method: http.MethodPatch, Path: "/api/u/:username/post/:post_id/comments/:comment_id", Tag: "Comments", Summary: "Edit your comment", Auth: openapi.AuthRequired, Body: model.UpdateCommentInput{}, Data: model.Comment{}},
+	{Method: http.MethodDelete, Path: "/api/u/:username/post/:post_id/comments/:comment_id", Tag: "Comments", Summary: "Delete your comment, or one on your post", Auth: openapi.AuthRequired},
+
+	{Method: http.MethodPost, Path: "/api/u/:username/post/:post_id/reactions", Tag: "Reactions", Summary: "React to a post; sending your current reaction again removes it", Auth: openapi.AuthRequired, Body: model.ReactionInput{}, Data: model.ReactionResp{}},
+	{Method: http.MethodPost, Path: "/api/u/:username/items/:item_id/reactions", Tag: "Reactions", Summary: "React to an item; sending your current reaction again removes it", Auth: openapi.AuthRequired, Body: model.ReactionInput{}, Data: model.ReactionResp{}},
+
+	{Method: http.MethodPost, Path: "/api/conversations", Tag: "Messages", Summary: "Start or resume a conversation", Auth: openapi.AuthRequired, Body: model.ConversationInput{}, Data: model.Conversation{}},
+	{Method: http.MethodGet, Path: "/api/conversations", Tag: "Messages", Summary: "List your conversations", Auth: openapi.AuthRequired, Query: paged, Data: model.ConversationsPageRes{}},
+	{Method: http.MethodGet, Path: "/api/conversations/:conversation_id/messages", Tag: "Messages", Summary: "List the messages of a conversation", Auth: openapi.AuthRequired, Query: paged, Data: model.MessagesPageRes{}},
+	{Method: http.MethodPost, Path: "/api/conversations/:conversation_id/messages", Tag: "Messages", Summary: "Send a message", Auth: openapi.AuthRequired, Body: model.MessageInput{}, Status: http.StatusCreated, Data: model.Message{}},
+	{Method: http.MethodPost, Path: "/api/conversations/:conversation_id/read", Tag: "Messages", Summary: "Mark a conversation read", Auth: openapi.AuthRequired},
+
+	{Method: http.MethodGet, Path: "/api/notifications", Tag: "Notifications", Summary: "List your notifications", Auth: openapi.AuthRequired, Query: pagedWith(
+		openapi.Param{Name: "unread", Value: false, Description: "Only unread notifications."},
+	), Data: model.NotificationsPageRes{}},
+	{Method: http.MethodPost, Path: "/api/notifications/read-all", Tag: "Notifications", Summary: "Mark all your notifications read and get their number as marked", Auth: openapi.AuthRequired, Data: map[string]int64{}},
+	{Method: http.MethodPost, Path: "/api/notifications/read/:notification_id", Tag: "Notifications", Summary: "Mark a notification read", Auth: openapi.AuthRequired},
+	{Method: http.MethodGet, Path: "/api/notifications/preferences", Tag: "Notifications", Summary: "Get your notification channels", Auth: openapi.AuthRequired, Data: model.NotificationPreferences{}},
+	{Method: http.MethodPatch, Path: "/api/notifications/preferences", Tag: "Notifications", Summary: "Change your notification channels", Auth: openapi.AuthRequired, Body: model.NotificationPreferences{}, Data: model.NotificationPreferences{}},
+
+	{Method: http.MethodPost, Path: "/api/webhooks", Tag: "Webhooks", Summary: "Subscribe a URL to events", Auth: openapi.AuthRequired, Body: model.WebhookInput{}, Status: http.StatusCreated, Data: model.Webhook{}},
+	{Method: http.MethodGet, Path: "/api/webhooks", Tag: "Webhooks", Summary: "List your webhooks", Auth: openapi.AuthRequired, Data: []model.Webhook{}},
+	{Method: http.MethodDelete, Path: "/api/webhooks/:webhook_id", Tag: "Webhooks", Summary: "Delete a webhook", Auth: openapi.AuthRequired},
+	{Method: http.MethodGet, Path: "/api/webhooks/:webhook_id/deliveries", Tag: "Webhooks", Summary: "List a webhook's deliveries", Auth: openapi.AuthRequired, Query: paged, Data: model.WebhookDeliveriesPageRes{}},
+	{Method: http.MethodPost, Path: "/api/webhooks/:webhook_id/deliveries/:delivery_id/redeliver", Tag: "Webhooks", Summary: "Send a delivery again", Auth: openapi.AuthRequired, Status: http.StatusAccepted, Data: model.WebhookDelivery{}},
+
+	{Method: http.MethodPost, Path: "/api/stream-tickets", Tag: "Real-time", Summary: "Get a single-use ticket to open a stream with", Auth: openapi.AuthRequired, Status: http.StatusCreated, Data: realtime.Ticket{}},
+	{Method: http.MethodGet, Path: "/api/ws", Tag: "Real-time", Summary: "WebSocket of your real-time events", Auth: openapi.AuthRequired, Query: []openapi.Param{ticket}, Status: http.StatusSwitchingProtocols},
+	{Method: http.MethodGet, Path: "/api/events", Tag: "Real-time", Summary: "Server-sent events of your real-time events", Auth: openapi.AuthRequired, Query: []openapi.Param{
+		ticket,
+		{Name: "last_event_id", Value: int64(0), Description: "Resume after this event, like the Last-Event-ID header."},
+	}, Content: "text/event-stream"},
+
+	{Method: http.MethodGet, Path: "/api/u/:username/trash/items", Tag: "Trash", Summary: "List your deleted items", Auth: openapi.AuthRequired, Query: paged, Data: model.ItemsPageRes{}},
+	{Method: http.MethodPost, Path: "/api/u/:username/trash/items/:item_id/restore", Tag: "Trash", Summary: "Restore a deleted item", Auth: openapi.AuthRequired, Data: model.ItemResp{}},
+	{Method: http.MethodGet, Path: "/api/u/:username/trash/post", Tag: "Trash", Summary: "List your deleted posts", Auth: openapi.AuthRequired, Query: paged, Data: model.PostsPageRes{}},
+	{Method: http.MethodPost, Path: "/api/u/:username/trash/post/:post_id/restore", Tag: "Trash", Summary: "Restore a deleted post", Auth: openapi.AuthRequired, Data: model.Post{}},
+}
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bagasadiii/buy-n-con/handler"
+	"github.com/bagasadiii/buy-n-con/internal/config"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/openapi"
+)
+
+// testRouter registers the routes with handlers that have no services;
+// registering them never calls into those.
+func testRouter(t *testing.T) *instrumentedRouter {
+	t.Helper()
+	tokens := middleware.NewTokenAuth(config.TokenConfig{Secret: "test"})
+	r, err := newRouter(&Routes{
+		User:         handler.NewUserHandler(nil, tokens),
+		Item:         handler.NewItemHandler(nil),
+		Post:         handler.NewPostHandler(nil),
+		Comment:      handler.NewCommentHandler(nil),
+		Reaction:     handler.NewReactionHandler(nil),
+		Follow:       handler.NewFollowHandler(nil),
+		Feed:         handler.NewFeedHandler(nil),
+		Tag:          handler.NewTagHandler(nil),
+		Message:      handler.NewMessageHandler(nil),
+		Realtime:     handler.NewRealtimeHandler(nil, tokens, nil, nil),
+		Notification: handler.NewNotificationHandler(nil),
+		Events:       handler.NewEventsHandler(nil, nil, tokens, nil),
+		Webhook:      handler.NewWebhookHandler(nil),
+		Health:       handler.NewHealthHandler(),
+	}, tokens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestEveryRouteHasAnOperation(t *testing.T) {
+	documented := map[string]bool{}
+	for _, op := range operations {
+		documented[op.Method+" "+op.Path] = true
+	}
+	for _, route := range testRouter(t).routes {
+		if !documented[route] {
+			t.Errorf("route %s has no entry in operations", route)
+		}
+	}
+}
+
+func TestEveryOperationHasARoute(t *testing.T) {
+	routed := map[string]bool{}
+	for _, route := range testRouter(t).routes {
+		routed[route] = true
+	}
+	for _, op := range operations {
+		if !routed[op.Method+" "+op.Path] {
+			t.Errorf("operation %s %s has no route", op.Method, op.Path)
+		}
+	}
+}
+
+func TestSpec(t *testing.T) {
+	spec, err := openapi.Build(apiInfo, operations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Paths      map[string]map[string]struct{ OperationID string }
+		Components struct{ Schemas map[string]json.RawMessage }
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := map[string]string{}
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			if other, ok := ids[op.OperationID]; ok {
+				t.Errorf("%s %s and %s share the operationId %s", method, path, other, op.OperationID)
+			}
+			ids[op.OperationID] = method + " " + path
+		}
+	}
+
+	const prefix = "#/components/schemas/"
+	for _, part := range strings.Split(string(spec), `"$ref": "`)[1:] {
+		ref, _, _ := strings.Cut(part, `"`)
+		if _, ok := doc.Components.Schemas[strings.TrimPrefix(ref, prefix)]; !ok || !strings.HasPrefix(ref, prefix) {
+			t.Errorf("$ref %s does not resolve", ref)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type CommentHandlerImpl interface {
+	CreateComment(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetComments(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetReplies(w http.ResponseWriter, r *http.Request, p router.Params)
+	UpdateComment(w http.ResponseWriter, r *http.Request, p router.Params)
+	DeleteComment(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type CommentHandler struct {
+	serv  service.CommentServiceImpl
+	valid *validator.Validate
+}
+
+func NewCommentHandler(serv service.CommentServiceImpl) CommentHandlerImpl {
+	return &CommentHandler{
+		serv:  serv,
+		valid: validate,
+	}
+}
+
+func (h *CommentHandler) CreateComment(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	if _, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey); !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid post ID"))
+		return
+	}
+	var input model.CommentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	getPost := model.GetPostInput{
+		PostID: postID,
+		Owner:  p.ByName("username"),
+	}
+	comment, err := h.serv.CreateCommentService(ctx, &input, &getPost)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusCreated,
+		Message: "comment created",
+		Data:    &comment,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *CommentHandler) GetComments(w http.ResponseWriter, r *http.Request, p router.Params) {
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid post ID"))
+		return
+	}
+	h.getCommentsPage(w, r, &model.CommentsPageReq{
+		PostID:    postID,
+		PostOwner: p.ByName("username"),
+	})
+}
+func (h *CommentHandler) GetReplies(w http.ResponseWriter, r *http.Request, p router.Params) {
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid post ID"))
+		return
+	}
+	commentID, err := uuid.Parse(p.ByName("comment_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid comment ID"))
+		return
+	}
+	h.getCommentsPage(w, r, &model.CommentsPageReq{
+		PostID:    postID,
+		PostOwner: p.ByName("username"),
+		ParentID:  &commentID,
+	})
+}
+func (h *CommentHandler) getCommentsPage(w http.ResponseWriter, r *http.Request, pageReq *model.CommentsPageReq) {
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	pageReq.Limit = limit
+	pageReq.Offset = offset
+	comments, err := h.serv.GetCommentsService(r.Context(), pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "comments fetched",
+		Data:    comments,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *CommentHandler) UpdateComment(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	getComment, err := parseCommentParams(p, userCtx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var input model.UpdateCommentInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	comment, err := h.serv.UpdateCommentService(ctx, &input, getComment)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "comment updated",
+		Data:    &comment,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *CommentHandler) DeleteComment(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	getComment, err := parseCommentParams(p, userCtx)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.serv.DeleteCommentService(ctx, getComment); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "comment deleted successfully",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+// parseCommentParams reads the post and comment IDs from the URL for a
+// request made by the authenticated user in userCtx.
+func parseCommentParams(p router.Params, userCtx *middleware.ContextKey) (*model.GetCommentInput, error) {
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		return nil, model.Invalid("invalid post ID")
+	}
+	commentID, err := uuid.Parse(p.ByName("comment_id"))
+	if err != nil {
+		return nil, model.Invalid("invalid comment ID")
+	}
+	return &model.GetCommentInput{
+		CommentID: commentID,
+		PostID:    postID,
+		PostOwner: p.ByName("username"),
+		Requester: userCtx.UsernameKey,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Errors the handlers report themselves, before reaching a service.
+var (
+	errUnauthenticated      = model.Unauthenticated("authentication required")
+	errInvalidToken         = model.Unauthenticated("expired or invalid token")
+	errInvalidTicket        = model.Unauthenticated("expired, used or invalid stream ticket")
+	errForbidden            = model.Forbidden("not allowed to access this resource")
+	errMalformedBody        = model.Invalid("malformed request body")
+	errStreamingUnsupported = errors.New("response writer does not support flushing")
+)
+
+// uniqueViolation is the SQLSTATE Postgres reports when a write would
+// duplicate a unique key.
+const uniqueViolation = "23505"
+
+// writeError answers r with the problem err maps to. Server errors are
+// logged with the request; client errors only at debug level, as the
+// access log already has their status. Errors of the request validator and
+// of models are listed field by field, in the client's language.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if invalid := fieldErrors(r, err); invalid != nil {
+		err = invalid
+	}
+	problem := problemFor(err)
+	if len(problem.Errors) > 0 {
+		problem.Errors = translateFields(translatorFor(r), problem.Errors)
+	}
+	if problem.Status >= http.StatusInternalServerError {
+		helper.ErrMsgCtx(r.Context(), err, "request failed: ")
+	} else {
+		helper.Log(r.Context()).WithError(err).Debug(problem.Title)
+	}
+	helper.WriteProblem(w, r, problem)
+}
+
+// problemFor is the one place errors become HTTP statuses. The detail shown
+// is the one a model.Error was given for clients; any other error text
+// stays in the logs, and errors of no known kind become a bare 500.
+func problemFor(err error) helper.Problem {
+	var known *model.Error
+	switch {
+	case errors.Is(err, model.ErrPreconditionFailed):
+		return helper.NewProblem(http.StatusPreconditionFailed, model.ErrPreconditionFailed.Error())
+	case isUniqueViolation(err):
+		return helper.NewProblem(http.StatusConflict, "resource already exists")
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return helper.NewProblem(http.StatusServiceUnavailable, "")
+	case !errors.As(err, &known):
+		return helper.NewProblem(http.StatusInternalServerError, "")
+	}
+	for _, kind := range []struct {
+		err    error
+		status int
+	}{
+		{model.ErrNotFound, http.StatusNotFound},
+		{model.ErrConflict, http.StatusConflict},
+		{model.ErrValidation, http.StatusBadRequest},
+		{model.ErrForbidden, http.StatusForbidden},
+		{model.ErrUnauthenticated, http.StatusUnauthorized},
+	} {
+		if errors.Is(known.Kind, kind.err) {
+			problem := helper.NewProblem(kind.status, known.Detail)
+			problem.Errors = known.Fields
+			return problem
+		}
+	}
+	return helper.NewProblem(http.StatusInternalServerError, "")
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+const (
+	replayBatch       = 100
+	keepAliveInterval = 25 * time.Second
+	retryAfterMillis  = 5000
+)
+
+type EventsHandlerImpl interface {
+	Stream(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type EventsHandler struct {
+	hub     *realtime.Hub
+	log     eventLog
+	tokens  *middleware.TokenAuth
+	tickets streamTickets
+}
+
+// eventLog is what Stream replays missed events from, a *realtime.EventLog.
+type eventLog interface {
+	Since(ctx context.Context, userID uuid.UUID, afterID int64, limit int) ([]realtime.Event, error)
+}
+
+func NewEventsHandler(hub *realtime.Hub, log *realtime.EventLog, tokens *middleware.TokenAuth, tickets *realtime.Tickets) EventsHandlerImpl {
+	return &EventsHandler{
+		hub:     hub,
+		log:     log,
+		tokens:  tokens,
+		tickets: tickets,
+	}
+}
+
+// Stream sends the caller's events as Server-Sent Events. With a
+// Last-Event-ID header (or ?last_event_id= on a first connect) everything
+// logged after that ID is replayed before live events.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userID, ok := streamAuth(w, r, h.tokens, h.tickets)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, errStreamingUnsupported)
+		return
+	}
+	lastID, err := lastEventID(r)
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid Last-Event-ID").Wrap(err))
+		return
+	}
+
+	// The stream outlives the server's WriteTimeout by design, so lift the
+	// deadline for this response; keep-alives detect dead clients instead.
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	// Subscribe before replaying so nothing committed in between is lost;
+	// live events already covered by the replay are skipped below.
+	sub := h.hub.Subscribe(userID)
+	defer h.hub.Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", retryAfterMillis)
+
+	// IDs are allocated at insert but transactions commit in any order, so
+	// a live event may carry a lower ID than one already sent. Only the
+	// events the replay actually sent are duplicates.
+	replayed := map[int64]bool{}
+	ctx := r.Context()
+	if lastID > 0 {
+		for {
+			events, err := h.log.Since(ctx, userID, lastID, replayBatch)
+			if err != nil {
+				return
+			}
+			for _, event := range events {
+				if err := writeSSE(w, event); err != nil {
+					return
+				}
+				replayed[event.ID] = true
+				lastID = event.ID
+			}
+			if len(events) < replayBatch {
+				break
+			}
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped for falling behind or on shutdown; the client
+				// resumes from its Last-Event-ID.
+				return
+			}
+			if replayed[event.ID] {
+				// Each event is published once, so it cannot repeat again.
+				delete(replayed, event.ID)
+				continue
+			}
+			if event.Truncated {
+				if full, err := h.log.Since(ctx, userID, event.ID-1, 1); err == nil && len(full) == 1 {
+					event = full[0]
+				}
+			}
+			if err := writeSSE(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, event realtime.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// lastEventID reads the ID the client last saw. Browsers send the header on
+// their own when they reconnect; the query parameter covers the first
+// connection of a page that remembers its position.
+func lastEventID(r *http.Request) (int64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/internal/config"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+	"github.com/google/uuid"
+)
+
+// fakeEventLog holds the logged events of one user, in ID order.
+type fakeEventLog []realtime.Event
+
+func (l fakeEventLog) Since(ctx context.Context, userID uuid.UUID, afterID int64, limit int) ([]realtime.Event, error) {
+	var events []realtime.Event
+	for _, event := range l {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func TestStreamResumesAndKeepsLateEvents(t *testing.T) {
+	tokens := middleware.NewTokenAuth(config.TokenConfig{Secret: "test", TTL: time.Hour})
+	userID := uuid.New()
+	token, err := tokens.GenerateToken(userID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub := realtime.NewHub()
+	event := func(id int64) realtime.Event {
+		return realtime.Event{ID: id, Type: realtime.EventNotificationCreated, Payload: []byte(`{}`)}
+	}
+	h := &EventsHandler{hub: hub, log: fakeEventLog{event(3), event(5), event(7)}, tokens: tokens}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Stream(w, r, nil)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Last-Event-ID", "4")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	var ids []string
+	lines := bufio.NewScanner(resp.Body)
+	for len(ids) < 4 && lines.Scan() {
+		id, ok := strings.CutPrefix(lines.Text(), "id: ")
+		if !ok {
+			continue
+		}
+		ids = append(ids, id)
+		if id == "7" {
+			// The replay is done and the stream is subscribed. 7 arrives
+			// live as well; 6 committed late, after 7 was sent.
+			hub.Deliver(userID, event(7))
+			hub.Deliver(userID, event(6))
+			hub.Deliver(userID, event(8))
+		}
+	}
+	if want := []string{"5", "7", "6", "8"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("event IDs = %v, want %v", ids, want)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type FeedHandlerImpl interface {
+	GetFeed(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type FeedHandler struct {
+	serv service.FeedServiceImpl
+}
+
+func NewFeedHandler(serv service.FeedServiceImpl) FeedHandlerImpl {
+	return &FeedHandler{serv: serv}
+}
+
+func (h *FeedHandler) GetFeed(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	req := &model.FeedReq{
+		UserID: userCtx.UserIDKey,
+		Limit:  limit,
+	}
+	if c := queryParams.Get("cursor"); c != "" {
+		cursor, err := model.ParseFeedCursor(c)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		req.Cursor = cursor
+	}
+	feed, err := h.serv.GetFeedService(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "feed fetched",
+		Data:    feed,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type FollowHandlerImpl interface {
+	Follow(w http.ResponseWriter, r *http.Request, p router.Params)
+	Unfollow(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetFollowers(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetFollowing(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type FollowHandler struct {
+	serv service.FollowServiceImpl
+}
+
+func NewFollowHandler(serv service.FollowServiceImpl) FollowHandlerImpl {
+	return &FollowHandler{serv: serv}
+}
+
+func (h *FollowHandler) Follow(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	if _, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey); !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	if err := h.serv.FollowService(ctx, p.ByName("username")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "user followed",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *FollowHandler) Unfollow(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	if _, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey); !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	if err := h.serv.UnfollowService(ctx, p.ByName("username")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "user unfollowed",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *FollowHandler) GetFollowers(w http.ResponseWriter, r *http.Request, p router.Params) {
+	page := followsPageReq(r, p)
+	users, err := h.serv.GetFollowersService(r.Context(), page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "followers fetched",
+		Data:    users,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *FollowHandler) GetFollowing(w http.ResponseWriter, r *http.Request, p router.Params) {
+	page := followsPageReq(r, p)
+	users, err := h.serv.GetFollowingService(r.Context(), page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "following fetched",
+		Data:    users,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func followsPageReq(r *http.Request, p router.Params) *model.FollowsPageReq {
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return &model.FollowsPageReq{
+		Username: p.ByName("username"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/buildinfo"
+	router "github.com/julienschmidt/httprouter"
+)
+
+const readyCheckTimeout = 2 * time.Second
+
+// HealthCheck is one dependency the server needs to take traffic. Check
+// returns nil when it is usable.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandlerImpl interface {
+	Healthz(w http.ResponseWriter, r *http.Request, p router.Params)
+	Readyz(w http.ResponseWriter, r *http.Request, p router.Params)
+	Version(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type HealthHandler struct {
+	checks []HealthCheck
+}
+
+func NewHealthHandler(checks ...HealthCheck) HealthHandlerImpl {
+	return &HealthHandler{
+		checks: checks,
+	}
+}
+
+// Healthz reports that the process is up and serving. It checks nothing
+// else, so a database outage does not get every instance restarted.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request, p router.Params) {
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "ok",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+// Readyz runs every check and answers 503 if any fails, with "ok" or "fail"
+// for each in data. The endpoint is public, so why a check failed, which
+// can name hosts or quote SQL, only goes to the log.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+	defer cancel()
+	results := make(map[string]string, len(h.checks))
+	ready := true
+	for _, check := range h.checks {
+		if err := check.Check(ctx); err != nil {
+			helper.Log(ctx).WithError(err).WithField("check", check.Name).Warn("readiness check failed")
+			results[check.Name] = "fail"
+			ready = false
+			continue
+		}
+		results[check.Name] = "ok"
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "ready",
+		Data:    results,
+		Err:     nil,
+	}
+	if !ready {
+		res.Status = http.StatusServiceUnavailable
+		res.Message = "not ready"
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+func (h *HealthHandler) Version(w http.ResponseWriter, r *http.Request, p router.Params) {
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "version fetched",
+		Data:    buildinfo.Get(),
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyzHidesCheckErrors(t *testing.T) {
+	h := NewHealthHandler(
+		HealthCheck{Name: "database", Check: func(ctx context.Context) error {
+			return errors.New("dial tcp db.internal:5432: connection refused")
+		}},
+		HealthCheck{Name: "migrations", Check: func(ctx context.Context) error { return nil }},
+	)
+	w := httptest.NewRecorder()
+	h.Readyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil), nil)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+	var res struct {
+		Data map[string]string
+	}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Data["database"] != "fail" || res.Data["migrations"] != "ok" {
+		t.Errorf("data = %v, want database fail and migrations ok", res.Data)
+	}
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 
@@ -15,94 +14,107 @@ import (
 	router "github.com/julienschmidt/httprouter"
 )
 
-type ItemHandlerImpl interface{
+type ItemHandlerImpl interface {
 	CreateItem(w http.ResponseWriter, r *http.Request, p router.Params)
 	GetItemByID(w http.ResponseWriter, r *http.Request, p router.Params)
 	GetAllItems(w http.ResponseWriter, r *http.Request, p router.Params)
 	UpdateItem(w http.ResponseWriter, r *http.Request, p router.Params)
 	DeleteItem(w http.ResponseWriter, r *http.Request, p router.Params)
+	UpdateItemStatus(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetDeletedItems(w http.ResponseWriter, r *http.Request, p router.Params)
+	RestoreItem(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetItemHistory(w http.ResponseWriter, r *http.Request, p router.Params)
 }
 type ItemHandler struct {
-	serv service.ItemServiceImpl
+	serv  service.ItemServiceImpl
 	valid *validator.Validate
 }
-func NewItemHandler(serv service.ItemServiceImpl)ItemHandlerImpl{
+
+func NewItemHandler(serv service.ItemServiceImpl) ItemHandlerImpl {
 	return &ItemHandler{
-		serv:serv,
-		valid: validator.New(),
+		serv:  serv,
+		valid: validate,
 	}
 }
 
-func(h *ItemHandler)CreateItem(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *ItemHandler) CreateItem(w http.ResponseWriter, r *http.Request, p router.Params) {
 	ctx := r.Context()
 	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
 	if !ok {
-		res := helper.UnauthorizedErr("Unauthorized: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errUnauthenticated)
 		return
 	}
 	username := p.ByName("username")
 	if username != userCtx.UsernameKey {
-		res := helper.ForbiddenErr("Forbidden access: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errForbidden)
 		return
 	}
 	var input model.CreateItemInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		res := helper.BadRequestErr("Bad request: validation failed", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errMalformedBody.Wrap(err))
 		return
 	}
 	if err := h.valid.Struct(&input); err != nil {
-		res := helper.BadRequestErr("Bad request", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	item, err := h.serv.CreateItemService(ctx, &input)
 	if err != nil {
-		res := helper.InternalErr("Failed to create item: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusCreated,
+		Status:  http.StatusCreated,
 		Message: "item created",
-		Data: &item,
-		Err: nil,
+		Data:    &item,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *ItemHandler)GetItemByID(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *ItemHandler) GetItemByID(w http.ResponseWriter, r *http.Request, p router.Params) {
 	username := p.ByName("username")
 	itemID, err := uuid.Parse(p.ByName("item_id"))
 	if err != nil {
-		res := helper.BadRequestErr("Bad request: Invalid item ID", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, model.Invalid("invalid item ID"))
 		return
 	}
+	viewer := ""
+	var viewerID uuid.UUID
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewer = userCtx.UsernameKey
+		viewerID = userCtx.UserIDKey
+	}
 	input := &model.GetItemInput{
-		ItemID: itemID,
-		Owner: username,
+		ItemID:   itemID,
+		Owner:    username,
+		Viewer:   viewer,
+		ViewerID: viewerID,
 	}
 	item, err := h.serv.GetItemByIDService(r.Context(), input)
 	if err != nil {
-		res := helper.InternalErr("Failed to fetch item: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
+		return
+	}
+	etag := helper.ETag(item.Version, item)
+	// The body shows the viewer's own reaction, so it depends on who asks.
+	w.Header().Add("Vary", "Authorization")
+	w.Header().Set("ETag", etag)
+	if helper.NoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "OK",
-		Data: &item,
-		Err: nil,
+		Data:    &item,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *ItemHandler)GetAllItems(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *ItemHandler) GetAllItems(w http.ResponseWriter, r *http.Request, p router.Params) {
 	username := p.ByName("username")
 	if username == "" {
-		res := helper.BadRequestErr("Username is required ", nil)
-		helper.JSONResponse(w, res.Status, nil)
+		writeError(w, r, model.Invalid("username is required"))
 		return
 	}
 	queryParams := r.URL.Query()
@@ -114,108 +126,287 @@ func(h *ItemHandler)GetAllItems(w http.ResponseWriter, r *http.Request, p router
 	if err != nil || offset < 0 {
 		offset = 0
 	}
+	viewer := ""
+	var viewerID uuid.UUID
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewer = userCtx.UsernameKey
+		viewerID = userCtx.UserIDKey
+	}
+	status := model.ItemStatus(queryParams.Get("status"))
+	if status != "" && !status.Public() && viewer != username {
+		writeError(w, r, errForbidden)
+		return
+	}
 	pageReq := &model.ItemsPageReq{
 		Username: username,
 		Limit:    limit,
 		Offset:   offset,
+		Status:   status,
+		Viewer:   viewer,
+		ViewerID: viewerID,
 	}
 	items, err := h.serv.GetAllItemsService(r.Context(), pageReq)
 	if err != nil {
-		res := helper.BadRequestErr("Bad request: unable to fetch items", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "Items fetched",
-		Data: items,
-		Err: nil,
+		Data:    items,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *ItemHandler)UpdateItem(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *ItemHandler) UpdateItem(w http.ResponseWriter, r *http.Request, p router.Params) {
 	ctx := r.Context()
 	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
 	if !ok {
-		res := helper.UnauthorizedErr("Unauthorized: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errUnauthenticated)
 		return
 	}
 	itemID, err := uuid.Parse(p.ByName("item_id"))
 	if err != nil {
-		res := helper.BadRequestErr("Invalid ID: item ID parsing failed", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, model.Invalid("invalid item ID"))
 		return
 	}
 	username := p.ByName("username")
 	if username != userCtx.UsernameKey {
-		res := helper.ForbiddenErr("Forbidden access: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errForbidden)
+		return
+	}
+	if !isPatchBody(r) {
+		helper.WriteProblem(w, r, helper.NewProblem(http.StatusUnsupportedMediaType, "use application/merge-patch+json"))
 		return
 	}
 	var input model.UpdateItemInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		res := helper.BadRequestErr("Bad request: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	getItem := model.GetItemInput{
-		ItemID: itemID,
-		Owner: username,
+		ItemID:   itemID,
+		Owner:    username,
+		Viewer:   userCtx.UsernameKey,
+		ViewerID: userCtx.UserIDKey,
+		IfMatch:  helper.ParseIfMatch(r.Header.Get("If-Match")),
 	}
 	updatedItem, err := h.serv.UpdateItemService(ctx, &input, &getItem)
 	if err != nil {
-		log.Println(itemID)
-		res := helper.Response{
-			Status: http.StatusInternalServerError,
-			Message: "invalid id",
-			Data: updatedItem,
-			Err: err.Error(),
-		}
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", helper.ETag(updatedItem.Version, updatedItem))
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "Item updated",
-		Data: &updatedItem,
-		Err: nil,
+		Data:    &updatedItem,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *ItemHandler)DeleteItem(w http.ResponseWriter, r *http.Request, p router.Params) { 
-    ctx := r.Context()
-    userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
-    if !ok {
-        res := helper.UnauthorizedErr("Unauthorized: ", nil)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
+func (h *ItemHandler) DeleteItem(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
 
-    itemID, err := uuid.Parse(p.ByName("item_id"))
-    if err != nil {
-        res := helper.BadRequestErr("Invalid ID: ", err)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
-    username := p.ByName("username")
-    if username != userCtx.UsernameKey {
-        res := helper.ForbiddenErr("Forbidden access: ", nil)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
-    err = h.serv.DeleteItemService(ctx, &itemID)
-    if err != nil {
-        res := helper.InternalErr("Failed to delete item: ", err)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
+	itemID, err := uuid.Parse(p.ByName("item_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid item ID"))
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
+	input := model.GetItemInput{
+		ItemID:   itemID,
+		Owner:    username,
+		Viewer:   userCtx.UsernameKey,
+		ViewerID: userCtx.UserIDKey,
+		IfMatch:  helper.ParseIfMatch(r.Header.Get("If-Match")),
+	}
+	err = h.serv.DeleteItemService(ctx, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-    res := helper.Response{
-        Status:  http.StatusOK,
-        Message: "Item deleted successfully",
-        Data:    nil,
-        Err:     nil,
-    }
-    helper.JSONResponse(w, res.Status, res)
-}
\ No newline at end of file
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "Item deleted successfully",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *ItemHandler) UpdateItemStatus(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	itemID, err := uuid.Parse(p.ByName("item_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid item ID"))
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
+	var input model.UpdateItemStatusInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	getItem := model.GetItemInput{
+		ItemID:   itemID,
+		Owner:    username,
+		Viewer:   userCtx.UsernameKey,
+		ViewerID: userCtx.UserIDKey,
+		IfMatch:  helper.ParseIfMatch(r.Header.Get("If-Match")),
+	}
+	item, err := h.serv.UpdateItemStatusService(ctx, &input, &getItem)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", helper.ETag(item.Version, item))
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "Item status updated",
+		Data:    &item,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *ItemHandler) GetDeletedItems(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	pageReq := &model.ItemsPageReq{
+		Username: username,
+		Limit:    limit,
+		Offset:   offset,
+	}
+	items, err := h.serv.GetDeletedItemsService(ctx, pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "Deleted items fetched",
+		Data:    items,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *ItemHandler) RestoreItem(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	itemID, err := uuid.Parse(p.ByName("item_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid item ID"))
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
+	input := model.GetItemInput{
+		ItemID: itemID,
+		Owner:  username,
+	}
+	item, err := h.serv.RestoreItemService(ctx, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "Item restored",
+		Data:    &item,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *ItemHandler) GetItemHistory(w http.ResponseWriter, r *http.Request, p router.Params) {
+	username := p.ByName("username")
+	itemID, err := uuid.Parse(p.ByName("item_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid item ID"))
+		return
+	}
+	viewer := ""
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewer = userCtx.UsernameKey
+	}
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 20
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	getItem := &model.GetItemInput{
+		ItemID: itemID,
+		Owner:  username,
+		Viewer: viewer,
+	}
+	pageReq := &model.ItemHistoryReq{
+		Limit:  limit,
+		Offset: offset,
+	}
+	history, err := h.serv.GetItemHistoryService(r.Context(), getItem, pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "Item history fetched",
+		Data:    history,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type MessageHandlerImpl interface {
+	StartConversation(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetConversations(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetMessages(w http.ResponseWriter, r *http.Request, p router.Params)
+	SendMessage(w http.ResponseWriter, r *http.Request, p router.Params)
+	MarkRead(w http.ResponseWriter, r *http.Request, p router.Params)
+	BlockUser(w http.ResponseWriter, r *http.Request, p router.Params)
+	UnblockUser(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type MessageHandler struct {
+	serv  service.MessageServiceImpl
+	valid *validator.Validate
+}
+
+func NewMessageHandler(serv service.MessageServiceImpl) MessageHandlerImpl {
+	return &MessageHandler{
+		serv:  serv,
+		valid: validate,
+	}
+}
+
+func (h *MessageHandler) StartConversation(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	var input model.ConversationInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	conversation, err := h.serv.StartConversationService(ctx, userCtx.UserIDKey, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "conversation started",
+		Data:    conversation,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *MessageHandler) GetConversations(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	limit, offset := pageParams(r, 20)
+	pageReq := &model.ConversationsPageReq{
+		UserID: userCtx.UserIDKey,
+		Limit:  limit,
+		Offset: offset,
+	}
+	conversations, err := h.serv.GetConversationsService(r.Context(), pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "conversations fetched",
+		Data:    conversations,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *MessageHandler) GetMessages(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	conversationID, err := uuid.Parse(p.ByName("conversation_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid conversation ID"))
+		return
+	}
+	limit, offset := pageParams(r, 50)
+	pageReq := &model.MessagesPageReq{
+		ConversationID: conversationID,
+		UserID:         userCtx.UserIDKey,
+		Limit:          limit,
+		Offset:         offset,
+	}
+	messages, err := h.serv.GetMessagesService(r.Context(), pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "messages fetched",
+		Data:    messages,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *MessageHandler) SendMessage(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	conversationID, err := uuid.Parse(p.ByName("conversation_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid conversation ID"))
+		return
+	}
+	var input model.MessageInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	message, err := h.serv.SendMessageService(ctx, userCtx.UserIDKey, conversationID, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusCreated,
+		Message: "message sent",
+		Data:    message,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *MessageHandler) MarkRead(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	conversationID, err := uuid.Parse(p.ByName("conversation_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid conversation ID"))
+		return
+	}
+	if err := h.serv.MarkReadService(r.Context(), userCtx.UserIDKey, conversationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "conversation read",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *MessageHandler) BlockUser(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	if err := h.serv.BlockUserService(r.Context(), userCtx.UserIDKey, p.ByName("username")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "user blocked",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *MessageHandler) UnblockUser(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	if err := h.serv.UnblockUserService(r.Context(), userCtx.UserIDKey, p.ByName("username")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "user unblocked",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+// pageParams reads limit and offset from the query string.
+func pageParams(r *http.Request, defaultLimit int) (int, int) {
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type NotificationHandlerImpl interface {
+	GetNotifications(w http.ResponseWriter, r *http.Request, p router.Params)
+	MarkRead(w http.ResponseWriter, r *http.Request, p router.Params)
+	MarkAllRead(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetPreferences(w http.ResponseWriter, r *http.Request, p router.Params)
+	UpdatePreferences(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type NotificationHandler struct {
+	serv service.NotificationServiceImpl
+}
+
+func NewNotificationHandler(serv service.NotificationServiceImpl) NotificationHandlerImpl {
+	return &NotificationHandler{
+		serv: serv,
+	}
+}
+
+func (h *NotificationHandler) GetNotifications(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	limit, offset := pageParams(r, 20)
+	pageReq := &model.NotificationsPageReq{
+		UserID:     userCtx.UserIDKey,
+		UnreadOnly: r.URL.Query().Get("unread") == "true",
+		Limit:      limit,
+		Offset:     offset,
+	}
+	notifications, err := h.serv.GetNotificationsService(r.Context(), pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "notifications fetched",
+		Data:    notifications,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	notificationID, err := uuid.Parse(p.ByName("notification_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid notification ID"))
+		return
+	}
+	if err := h.serv.MarkNotificationReadService(r.Context(), userCtx.UserIDKey, notificationID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "notification read",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	marked, err := h.serv.MarkAllNotificationsReadService(r.Context(), userCtx.UserIDKey)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "notifications read",
+		Data:    map[string]int64{"marked": marked},
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	prefs, err := h.serv.GetNotificationPreferencesService(r.Context(), userCtx.UserIDKey)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "notification preferences fetched",
+		Data:    prefs,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	var input model.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	prefs, err := h.serv.UpdateNotificationPreferencesService(r.Context(), userCtx.UserIDKey, input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "notification preferences updated",
+		Data:    prefs,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
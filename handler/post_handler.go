@@ -9,93 +9,110 @@ import (
 	"github.com/bagasadiii/buy-n-con/internal/middleware"
 	"github.com/bagasadiii/buy-n-con/internal/model"
 	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	router "github.com/julienschmidt/httprouter"
 )
 
-type PostHandlerImpl interface{
+type PostHandlerImpl interface {
 	CreatePost(w http.ResponseWriter, r *http.Request, p router.Params)
 	GetPostByID(w http.ResponseWriter, r *http.Request, p router.Params)
 	GetAllPosts(w http.ResponseWriter, r *http.Request, p router.Params)
 	UpdatePost(w http.ResponseWriter, r *http.Request, p router.Params)
 	DeletePost(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetDeletedPosts(w http.ResponseWriter, r *http.Request, p router.Params)
+	RestorePost(w http.ResponseWriter, r *http.Request, p router.Params)
 }
 
 type PostHandler struct {
-	serv service.PostServiceImpl
+	serv  service.PostServiceImpl
+	valid *validator.Validate
 }
 
-func NewPostHandler(serv service.PostServiceImpl)PostHandlerImpl{
+func NewPostHandler(serv service.PostServiceImpl) PostHandlerImpl {
 	return &PostHandler{
-		serv: serv,
+		serv:  serv,
+		valid: validate,
 	}
 }
 
-func(h *PostHandler)CreatePost(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request, p router.Params) {
 	ctx := r.Context()
 	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
 	if !ok {
-		res := helper.UnauthorizedErr("Unauthorized: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errUnauthenticated)
 		return
 	}
 	username := p.ByName("username")
 	if username != userCtx.UsernameKey {
-		res := helper.ForbiddenErr("Forbidden access: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errForbidden)
 		return
 	}
 	var input model.PostInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		res := helper.InternalErr("Internal error: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	post, err := h.serv.CreatePostService(ctx, &input)
 	if err != nil {
-		res := helper.InternalErr("Failed to create post: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusCreated,
+		Status:  http.StatusCreated,
 		Message: "post created",
-		Data: &post,
-		Err: nil,
+		Data:    &post,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *PostHandler)GetPostByID(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *PostHandler) GetPostByID(w http.ResponseWriter, r *http.Request, p router.Params) {
 	username := p.ByName("username")
 	postID, err := uuid.Parse(p.ByName("post_id"))
 	if err != nil {
-		res := helper.BadRequestErr("Bad request: Invalid post ID", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, model.Invalid("invalid post ID"))
 		return
 	}
+	var viewerID uuid.UUID
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewerID = userCtx.UserIDKey
+	}
 	input := &model.GetPostInput{
-		PostID: postID,
-		Owner: username,
+		PostID:   postID,
+		Owner:    username,
+		ViewerID: viewerID,
 	}
 	post, err := h.serv.GetPostByIDService(r.Context(), input)
 	if err != nil {
-		res := helper.InternalErr("Failed to fetch post: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
+		return
+	}
+	// The tag hashes the body, so it follows the live item cards and the
+	// counters, none of which bump the post's version. The body also shows
+	// the viewer's own reaction, so it depends on who asks.
+	etag := helper.ETag(post.Version, post)
+	w.Header().Add("Vary", "Authorization")
+	w.Header().Set("ETag", etag)
+	if helper.NoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "OK",
-		Data: &post,
-		Err: nil,
+		Data:    &post,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *PostHandler)GetAllPosts(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *PostHandler) GetAllPosts(w http.ResponseWriter, r *http.Request, p router.Params) {
 	username := p.ByName("username")
 	if username == "" {
-		res := helper.BadRequestErr("Username is required ", nil)
-		helper.JSONResponse(w, res.Status, nil)
+		writeError(w, r, model.Invalid("username is required"))
 		return
 	}
 	queryParams := r.URL.Query()
@@ -107,111 +124,192 @@ func(h *PostHandler)GetAllPosts(w http.ResponseWriter, r *http.Request, p router
 	if err != nil || offset < 0 {
 		offset = 0
 	}
+	var viewerID uuid.UUID
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewerID = userCtx.UserIDKey
+	}
 	pageReq := &model.PostsPageReq{
 		Username: username,
 		Limit:    limit,
 		Offset:   offset,
+		ViewerID: viewerID,
 	}
 	posts, err := h.serv.GetAllPostService(r.Context(), pageReq)
 	if err != nil {
-		res := helper.InternalErr("Bad request: unable to fetch posts", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "posts fetched",
-		Data: posts,
-		Err: nil,
+		Data:    posts,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *PostHandler)UpdatePost(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request, p router.Params) {
 	ctx := r.Context()
 	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
 	if !ok {
-		res := helper.UnauthorizedErr("Unauthorized: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errUnauthenticated)
 		return
 	}
 	postID, err := uuid.Parse(p.ByName("post_id"))
 	if err != nil {
-		res := helper.BadRequestErr("Invalid ID: post ID parsing failed", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, model.Invalid("invalid post ID"))
 		return
 	}
 	username := p.ByName("username")
 	if username != userCtx.UsernameKey {
-		res := helper.ForbiddenErr("Forbidden access: ", nil)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errForbidden)
+		return
+	}
+	if !isPatchBody(r) {
+		helper.WriteProblem(w, r, helper.NewProblem(http.StatusUnsupportedMediaType, "use application/merge-patch+json"))
 		return
 	}
 	var input model.UpdatePostInput
 	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
-		res := helper.BadRequestErr("Bad request: ", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	getPost := model.GetPostInput{
-		PostID: postID,
-		Owner: username,
+		PostID:   postID,
+		Owner:    username,
+		ViewerID: userCtx.UserIDKey,
+		IfMatch:  helper.ParseIfMatch(r.Header.Get("If-Match")),
 	}
 	updatedPost, err := h.serv.UpdatePostService(ctx, &input, &getPost)
 	if err != nil {
-		res := helper.Response{
-			Status: http.StatusInternalServerError,
-			Message: "invalid id",
-			Data: &updatedPost,
-			Err: err.Error(),
-		}
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", helper.ETag(updatedPost.Version, updatedPost))
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "post updated",
-		Data: &updatedPost,
-		Err: nil,
+		Data:    &updatedPost,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
-func(h *PostHandler)DeletePost(w http.ResponseWriter, r *http.Request, p router.Params) { 
-    ctx := r.Context()
-    userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
-    if !ok {
-        res := helper.UnauthorizedErr("Unauthorized: ", nil)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
+func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
 
-    postID, err := uuid.Parse(p.ByName("post_id"))
-    if err != nil {
-        res := helper.BadRequestErr("Invalid ID: ", err)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
-    username := p.ByName("username")
-    if username != userCtx.UsernameKey {
-        res := helper.ForbiddenErr("Forbidden access: ", nil)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid post ID"))
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
 	input := model.GetPostInput{
-		PostID: postID,
-		Owner: username,
-	}
-    err = h.serv.DeletePostService(ctx, &input)
-    if err != nil {
-        res := helper.InternalErr("Failed to delete post: ", err)
-        helper.JSONResponse(w, res.Status, res)
-        return
-    }
+		PostID:   postID,
+		Owner:    username,
+		ViewerID: userCtx.UserIDKey,
+		IfMatch:  helper.ParseIfMatch(r.Header.Get("If-Match")),
+	}
+	err = h.serv.DeletePostService(ctx, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-    res := helper.Response{
-        Status:  http.StatusOK,
-        Message: "post deleted successfully",
-        Data:    nil,
-        Err:     nil,
-    }
-    helper.JSONResponse(w, res.Status, res)
-}
\ No newline at end of file
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "post deleted successfully",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *PostHandler) GetDeletedPosts(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	var viewerID uuid.UUID
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewerID = userCtx.UserIDKey
+	}
+	pageReq := &model.PostsPageReq{
+		Username: username,
+		Limit:    limit,
+		Offset:   offset,
+		ViewerID: viewerID,
+	}
+	posts, err := h.serv.GetDeletedPostsService(ctx, pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "deleted posts fetched",
+		Data:    posts,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *PostHandler) RestorePost(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid post ID"))
+		return
+	}
+	username := p.ByName("username")
+	if username != userCtx.UsernameKey {
+		writeError(w, r, errForbidden)
+		return
+	}
+	input := model.GetPostInput{
+		PostID: postID,
+		Owner:  username,
+	}
+	post, err := h.serv.RestorePostService(ctx, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "post restored",
+		Data:    &post,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type ReactionHandlerImpl interface {
+	ReactToPost(w http.ResponseWriter, r *http.Request, p router.Params)
+	ReactToItem(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type ReactionHandler struct {
+	serv  service.ReactionServiceImpl
+	valid *validator.Validate
+}
+
+func NewReactionHandler(serv service.ReactionServiceImpl) ReactionHandlerImpl {
+	return &ReactionHandler{
+		serv:  serv,
+		valid: validate,
+	}
+}
+
+func (h *ReactionHandler) ReactToPost(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	if _, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey); !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	postID, err := uuid.Parse(p.ByName("post_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid post ID"))
+		return
+	}
+	input, err := h.decodeReaction(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	getPost := model.GetPostInput{
+		PostID: postID,
+		Owner:  p.ByName("username"),
+	}
+	reaction, err := h.serv.ReactToPostService(ctx, input, &getPost)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "reaction saved",
+		Data:    reaction,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *ReactionHandler) ReactToItem(w http.ResponseWriter, r *http.Request, p router.Params) {
+	ctx := r.Context()
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	itemID, err := uuid.Parse(p.ByName("item_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid item ID"))
+		return
+	}
+	input, err := h.decodeReaction(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	getItem := model.GetItemInput{
+		ItemID: itemID,
+		Owner:  p.ByName("username"),
+		Viewer: userCtx.UsernameKey,
+	}
+	reaction, err := h.serv.ReactToItemService(ctx, input, &getItem)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "reaction saved",
+		Data:    reaction,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *ReactionHandler) decodeReaction(r *http.Request) (*model.ReactionInput, error) {
+	var input model.ReactionInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		return nil, errMalformedBody.Wrap(err)
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		return nil, err
+	}
+	return &input, nil
+}
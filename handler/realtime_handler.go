@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type RealtimeHandlerImpl interface {
+	Connect(w http.ResponseWriter, r *http.Request, p router.Params)
+	IssueTicket(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type RealtimeHandler struct {
+	hub      *realtime.Hub
+	tokens   *middleware.TokenAuth
+	tickets  streamTickets
+	upgrader websocket.Upgrader
+}
+
+// streamTickets issues and redeems the tickets browsers open streams with,
+// a *realtime.Tickets.
+type streamTickets interface {
+	Issue(ctx context.Context, userID uuid.UUID) (*realtime.Ticket, error)
+	Redeem(ctx context.Context, ticket string) (uuid.UUID, error)
+}
+
+// NewRealtimeHandler accepts WebSocket handshakes from the same origin or
+// from one of allowedOrigins, matching the CORS setup.
+func NewRealtimeHandler(hub *realtime.Hub, tokens *middleware.TokenAuth, tickets *realtime.Tickets, allowedOrigins []string) RealtimeHandlerImpl {
+	return &RealtimeHandler{
+		hub:     hub,
+		tokens:  tokens,
+		tickets: tickets,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				if origin == "" || strings.HasSuffix(origin, "://"+r.Host) {
+					return true
+				}
+				for _, allowed := range allowedOrigins {
+					if origin == allowed {
+						return true
+					}
+				}
+				return false
+			},
+		},
+	}
+}
+
+// Connect upgrades to a WebSocket for the caller.
+func (h *RealtimeHandler) Connect(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userID, ok := streamAuth(w, r, h.tokens, h.tickets)
+	if !ok {
+		return
+	}
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		helper.ErrMsg(err, "failed to upgrade connection: ")
+		return
+	}
+	realtime.Serve(h.hub, conn, userID)
+}
+
+// IssueTicket hands the caller a ticket to open one stream with.
+func (h *RealtimeHandler) IssueTicket(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	ticket, err := h.tickets.Issue(r.Context(), userCtx.UserIDKey)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusCreated,
+		Message: "stream ticket issued",
+		Data:    ticket,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+// streamAuth authenticates a WebSocket or SSE request by its Authorization
+// header or, since browsers cannot set headers on either, by a ticket from
+// IssueTicket passed as ?ticket=. Access tokens are never read from the URL,
+// where proxies and browser history would keep them.
+func streamAuth(w http.ResponseWriter, r *http.Request, tokens *middleware.TokenAuth, tickets streamTickets) (uuid.UUID, bool) {
+	var userID uuid.UUID
+	if header := r.Header.Get("Authorization"); header != "" {
+		validation := tokens.ValidateToken(strings.TrimPrefix(header, "Bearer "))
+		if validation.Err != nil {
+			writeError(w, r, errInvalidToken.Wrap(validation.Err))
+			return uuid.Nil, false
+		}
+		userID = validation.ID
+	} else if ticket := r.URL.Query().Get("ticket"); ticket != "" {
+		var err error
+		userID, err = tickets.Redeem(r.Context(), ticket)
+		if errors.Is(err, realtime.ErrInvalidTicket) {
+			writeError(w, r, errInvalidTicket)
+			return uuid.Nil, false
+		}
+		if err != nil {
+			writeError(w, r, err)
+			return uuid.Nil, false
+		}
+	} else {
+		writeError(w, r, errUnauthenticated)
+		return uuid.Nil, false
+	}
+	helper.SetLogUser(r.Context(), userID.String())
+	return userID, true
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/internal/config"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+	"github.com/google/uuid"
+)
+
+// fakeTickets redeems each ticket once, like realtime.Tickets.
+type fakeTickets map[string]uuid.UUID
+
+func (t fakeTickets) Issue(ctx context.Context, userID uuid.UUID) (*realtime.Ticket, error) {
+	ticket := uuid.NewString()
+	t[ticket] = userID
+	return &realtime.Ticket{Ticket: ticket, ExpiresAt: time.Now().Add(realtime.TicketTTL)}, nil
+}
+
+func (t fakeTickets) Redeem(ctx context.Context, ticket string) (uuid.UUID, error) {
+	userID, ok := t[ticket]
+	if !ok {
+		return uuid.Nil, realtime.ErrInvalidTicket
+	}
+	delete(t, ticket)
+	return userID, nil
+}
+
+func TestStreamAuth(t *testing.T) {
+	tokens := middleware.NewTokenAuth(config.TokenConfig{Secret: "test", TTL: time.Hour})
+	userID := uuid.New()
+	token, err := tokens.GenerateToken(userID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets := fakeTickets{}
+	ticket, _ := tickets.Issue(context.Background(), userID)
+
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		want   int
+	}{
+		{"bearer header", "Bearer " + token, "", http.StatusOK},
+		{"bad bearer header", "Bearer nope", "", http.StatusUnauthorized},
+		{"access token in the URL", "", "?token=" + token, http.StatusUnauthorized},
+		{"ticket", "", "?ticket=" + ticket.Ticket, http.StatusOK},
+		{"used ticket", "", "?ticket=" + ticket.Ticket, http.StatusUnauthorized},
+		{"unknown ticket", "", "?ticket=nope", http.StatusUnauthorized},
+		{"nothing", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/api/events"+tt.query, nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		got, ok := streamAuth(w, r, tokens, tickets)
+		if tt.want == http.StatusOK {
+			if !ok || got != userID {
+				t.Errorf("%s: user %s, ok %t; want %s (response %d %s)", tt.name, got, ok, userID, w.Code, w.Body)
+			}
+			continue
+		}
+		if ok || w.Code != tt.want {
+			t.Errorf("%s: ok %t, status %d; want %d", tt.name, ok, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type TagHandlerImpl interface {
+	GetPostsByTag(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetTrendingTags(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type TagHandler struct {
+	serv service.TagServiceImpl
+}
+
+func NewTagHandler(serv service.TagServiceImpl) TagHandlerImpl {
+	return &TagHandler{serv: serv}
+}
+
+func (h *TagHandler) GetPostsByTag(w http.ResponseWriter, r *http.Request, p router.Params) {
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	offset, err := strconv.Atoi(queryParams.Get("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	var viewerID uuid.UUID
+	if userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey); ok {
+		viewerID = userCtx.UserIDKey
+	}
+	pageReq := &model.TagPostsPageReq{
+		Tag:      p.ByName("tag"),
+		Limit:    limit,
+		Offset:   offset,
+		ViewerID: viewerID,
+	}
+	posts, err := h.serv.GetPostsByTagService(r.Context(), pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "posts fetched",
+		Data:    posts,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+
+// GetTrendingTags takes the sliding window as a Go duration, e.g. ?window=6h.
+func (h *TagHandler) GetTrendingTags(w http.ResponseWriter, r *http.Request, p router.Params) {
+	queryParams := r.URL.Query()
+	var window time.Duration
+	if raw := queryParams.Get("window"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			writeError(w, r, model.Invalid("invalid window").Wrap(err))
+			return
+		}
+		window = parsed
+	}
+	limit, err := strconv.Atoi(queryParams.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	tags, err := h.serv.GetTrendingTagsService(r.Context(), window, limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "trending tags fetched",
+		Data:    tags,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
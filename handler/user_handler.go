@@ -18,91 +18,86 @@ type UserHandlerImpl interface {
 	GetUserByUsername(w http.ResponseWriter, r *http.Request, p router.Params)
 }
 type UserHandler struct {
-	serv service.UserServiceImpl
-	valid *validator.Validate
+	serv   service.UserServiceImpl
+	tokens *middleware.TokenAuth
+	valid  *validator.Validate
 }
-func NewUserHandler(serv service.UserServiceImpl)UserHandlerImpl{
+
+func NewUserHandler(serv service.UserServiceImpl, tokens *middleware.TokenAuth) UserHandlerImpl {
 	return &UserHandler{
-		serv:serv,
-		valid: validator.New(),
+		serv:   serv,
+		tokens: tokens,
+		valid:  validate,
 	}
 }
 
-func(h *UserHandler)Register(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *UserHandler) Register(w http.ResponseWriter, r *http.Request, p router.Params) {
 	var input model.RegisterInput
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
-		res := helper.BadRequestErr("Bad request", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errMalformedBody.Wrap(err))
 		return
 	}
 	if err := h.valid.Struct(&input); err != nil {
-		res := helper.BadRequestErr("Fill required form", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	user, err := h.serv.RegisterService(r.Context(), &input)
 	if err != nil {
-		res := helper.InternalErr("Internal server error while register", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusCreated,
+		Status:  http.StatusCreated,
 		Message: "user created",
-		Data: &user,
-		Err: nil,
+		Data:    &user,
+		Err:     nil,
 	}
 	helper.JSONResponse(w, res.Status, res)
 }
 
-func(h *UserHandler)Login(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request, p router.Params) {
 	var input model.LoginInput
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
-		res := helper.BadRequestErr("Bad request", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, errMalformedBody.Wrap(err))
 		return
 	}
 	if err := h.valid.Struct(&input); err != nil {
-		res := helper.BadRequestErr("Fill required form", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	token, err := h.serv.LoginService(r.Context(), &input)
 	if err != nil {
-		res := helper.BadRequestErr("Invalid Login", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
-	validate := middleware.ValidateToken(token)
+	validate := h.tokens.ValidateToken(token)
 	if validate.Err != nil {
-		res := helper.InternalErr("Validation token error", validate.Err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, validate.Err)
 		return
 	}
 	res := helper.Response{
-        Status: http.StatusOK,
-        Message: "Login successful",
-        Data: validate,
-        Err: nil,
-    }
-    helper.JSONResponse(w, res.Status, res)
+		Status:  http.StatusOK,
+		Message: "Login successful",
+		Data:    validate,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
 }
 
-func(h *UserHandler)GetUserByUsername(w http.ResponseWriter, r *http.Request, p router.Params){
+func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request, p router.Params) {
 	username := p.ByName("username")
 	user, err := h.serv.GetUserService(r.Context(), username)
 	if err != nil {
-		res := helper.BadRequestErr("Bad request", err)
-		helper.JSONResponse(w, res.Status, res)
+		writeError(w, r, err)
 		return
 	}
 	res := helper.Response{
-		Status: http.StatusOK,
+		Status:  http.StatusOK,
 		Message: "OK",
-		Data: user,
-		Err: nil,
+		Data:    user,
+		Err:     nil,
 	}
-    helper.JSONResponse(w, res.Status, res)
-}
\ No newline at end of file
+	helper.JSONResponse(w, res.Status, res)
+}
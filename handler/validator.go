@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+	"github.com/google/uuid"
+)
+
+type optionalField interface {
+	FieldValue() any
+}
+
+// translation is one supported language of validation messages.
+type translation struct {
+	locale locales.Translator
+	// builtin registers the messages of the validator's own rules.
+	builtin func(v *validator.Validate, trans ut.Translator) error
+	// rules holds the messages of this repo's rules, keyed like validator
+	// tags. {0} is the field. The username rule is also a validator rule,
+	// so its message is registered with it; the others only models check.
+	rules map[string]string
+}
+
+var translations = []translation{
+	{
+		locale:  en.New(),
+		builtin: en_translations.RegisterDefaultTranslations,
+		rules: map[string]string{
+			"username":   "{0} can only contain lowercase letters, digits and underscores",
+			"public_url": "{0} must be an http or https URL of a public host",
+			"attachable": "{0} can only list your own items that are active, reserved or sold",
+		},
+	},
+	{
+		locale:  id.New(),
+		builtin: id_translations.RegisterDefaultTranslations,
+		rules: map[string]string{
+			"username":   "{0} hanya boleh berisi huruf kecil, angka, dan garis bawah",
+			"public_url": "{0} harus berupa URL http atau https dari host publik",
+			"attachable": "{0} hanya boleh berisi barang Anda sendiri yang aktif, dipesan, atau terjual",
+		},
+	},
+}
+
+// translators holds the messages of validation errors, one translator per
+// entry of translations. English is the fallback.
+var translators = newTranslators()
+
+// validate is shared by all handlers, since translations are registered
+// once per validator and translator pair.
+var validate = newValidator()
+
+func newTranslators() *ut.UniversalTranslator {
+	supported := make([]locales.Translator, len(translations))
+	for i, lang := range translations {
+		supported[i] = lang.locale
+	}
+	return ut.New(en.New(), supported...)
+}
+
+// newValidator returns a validator that understands model.Optional fields,
+// so rules on a patch body only run for the fields that were actually sent.
+// Fields are named by their JSON keys, and the username rule of
+// model.ValidUsername is available as "username".
+func newValidator() *validator.Validate {
+	valid := validator.New()
+	valid.RegisterCustomTypeFunc(func(field reflect.Value) any {
+		return field.Interface().(optionalField).FieldValue()
+	}, model.Optional[string]{}, model.Optional[int]{}, model.Optional[[]uuid.UUID]{})
+	valid.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	valid.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+		return model.ValidUsername(fl.Field().String())
+	})
+
+	for _, lang := range translations {
+		trans, _ := translators.GetTranslator(lang.locale.Locale())
+		if err := lang.builtin(valid, trans); err != nil {
+			panic(err)
+		}
+		for rule, message := range lang.rules {
+			if err := trans.Add(rule, message, false); err != nil {
+				panic(err)
+			}
+		}
+		err := valid.RegisterTranslation("username", trans, func(ut.Translator) error {
+			return nil
+		}, func(t ut.Translator, fe validator.FieldError) string {
+			msg, _ := t.T("username", fe.Field())
+			return msg
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return valid
+}
+
+// translatorFor picks the translator for the languages the client accepts,
+// in the order it lists them. A regional tag such as id-ID falls back to its
+// language when the region has no translator of its own.
+func translatorFor(r *http.Request) ut.Translator {
+	var accepted []string
+	for _, lang := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(lang), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		tag = strings.ReplaceAll(tag, "-", "_")
+		accepted = append(accepted, tag)
+		if base, _, ok := strings.Cut(tag, "_"); ok {
+			accepted = append(accepted, strings.ToLower(base))
+		}
+	}
+	trans, _ := translators.FindTranslator(accepted...)
+	return trans
+}
+
+// fieldErrors turns the errors of validate into a validation error naming
+// each broken rule, with messages in the client's language. It returns nil
+// for any other error.
+func fieldErrors(r *http.Request, err error) *model.Error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return nil
+	}
+	trans := translatorFor(r)
+	fields := make([]helper.FieldError, len(invalid))
+	for i, fe := range invalid {
+		// The namespace starts with the struct name; the rest is the JSON
+		// path of the field, such as events[0].
+		_, field, _ := strings.Cut(fe.Namespace(), ".")
+		fields[i] = helper.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		}
+	}
+	return model.InvalidFields(fields...).Wrap(err)
+}
+
+// translateFields fills in the messages that model errors leave to the
+// handler, in the language of trans. It copies fields, since model errors
+// are shared.
+func translateFields(trans ut.Translator, fields []helper.FieldError) []helper.FieldError {
+	translated := make([]helper.FieldError, len(fields))
+	for i, field := range fields {
+		if field.Message == "" {
+			msg, err := trans.T(field.Rule, field.Field)
+			if err != nil {
+				msg = field.Field + " is invalid"
+			}
+			field.Message = msg
+		}
+		translated[i] = field
+	}
+	return translated
+}
+
+// isPatchBody reports whether a PATCH request body can be read as a JSON
+// merge patch. A missing Content-Type is accepted as plain JSON.
+func isPatchBody(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/merge-patch+json" || mediaType == "application/json"
+}
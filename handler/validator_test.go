@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+)
+
+func problemOf(t *testing.T, err error) helper.Problem {
+	t.Helper()
+	return problemIn(t, "fr-CA, en;q=0.8", err)
+}
+
+func problemIn(t *testing.T, acceptLanguage string, err error) helper.Problem {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/api/register", nil)
+	r.Header.Set("Accept-Language", acceptLanguage)
+	writeError(w, r, err)
+	var problem helper.Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	return problem
+}
+
+func TestValidatorFieldErrors(t *testing.T) {
+	input := model.RegisterInput{Username: "Bob", Email: "bob", Password: "secret"}
+	problem := problemOf(t, validate.Struct(&input))
+	if problem.Status != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", problem.Status)
+	}
+	want := map[string]helper.FieldError{
+		"username": {Field: "username", Rule: "username", Message: "username can only contain lowercase letters, digits and underscores"},
+		"email":    {Field: "email", Rule: "email", Message: "email must be a valid email address"},
+	}
+	if len(problem.Errors) != len(want) {
+		t.Fatalf("errors = %+v", problem.Errors)
+	}
+	for _, got := range problem.Errors {
+		if got != want[got.Field] {
+			t.Errorf("got %+v, want %+v", got, want[got.Field])
+		}
+	}
+}
+
+func TestModelFieldErrorsAreTranslated(t *testing.T) {
+	_, err := model.NewUser(&model.RegisterInput{Username: "Bob", Email: "bob@example.com", Password: "secret"})
+	problem := problemOf(t, err)
+	want := helper.FieldError{Field: "username", Rule: "username", Message: "username can only contain lowercase letters, digits and underscores"}
+	if len(problem.Errors) != 1 || problem.Errors[0] != want {
+		t.Errorf("errors = %+v, want %+v", problem.Errors, want)
+	}
+	if model.ErrInvalidUsername.Fields[0].Message != "" {
+		t.Error("translating changed the shared model error")
+	}
+
+	problem = problemOf(t, model.ErrWebhookURL)
+	if len(problem.Errors) != 1 || problem.Errors[0].Message != "url must be an http or https URL of a public host" {
+		t.Errorf("errors = %+v", problem.Errors)
+	}
+}
+
+func TestFieldErrorsFollowAcceptLanguage(t *testing.T) {
+	input := model.RegisterInput{Username: "bob", Email: "bob@example.com"}
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"id", "password wajib diisi"},
+		{"id-ID, en;q=0.5", "password wajib diisi"},
+		{"fr, id;q=0.9", "password wajib diisi"},
+		{"en-GB, id;q=0.9", "password is a required field"},
+		{"fr", "password is a required field"},
+		{"", "password is a required field"},
+	}
+	for _, tt := range tests {
+		problem := problemIn(t, tt.acceptLanguage, validate.Struct(&input))
+		if len(problem.Errors) != 1 || problem.Errors[0].Message != tt.want {
+			t.Errorf("%q: errors = %+v, want %q", tt.acceptLanguage, problem.Errors, tt.want)
+		}
+	}
+
+	_, err := model.NewUser(&model.RegisterInput{Username: "Bob", Email: "bob@example.com", Password: "secret"})
+	problem := problemIn(t, "id", err)
+	want := "username hanya boleh berisi huruf kecil, angka, dan garis bawah"
+	if len(problem.Errors) != 1 || problem.Errors[0].Message != want {
+		t.Errorf("errors = %+v, want %q", problem.Errors, want)
+	}
+}
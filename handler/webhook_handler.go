@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/service"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	router "github.com/julienschmidt/httprouter"
+)
+
+type WebhookHandlerImpl interface {
+	CreateWebhook(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetWebhooks(w http.ResponseWriter, r *http.Request, p router.Params)
+	DeleteWebhook(w http.ResponseWriter, r *http.Request, p router.Params)
+	GetDeliveries(w http.ResponseWriter, r *http.Request, p router.Params)
+	Redeliver(w http.ResponseWriter, r *http.Request, p router.Params)
+}
+type WebhookHandler struct {
+	serv  service.WebhookServiceImpl
+	valid *validator.Validate
+}
+
+func NewWebhookHandler(serv service.WebhookServiceImpl) WebhookHandlerImpl {
+	return &WebhookHandler{
+		serv:  serv,
+		valid: validate,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	var input model.WebhookInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		writeError(w, r, errMalformedBody.Wrap(err))
+		return
+	}
+	if err := h.valid.Struct(&input); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	webhook, err := h.serv.CreateWebhookService(r.Context(), userCtx.UserIDKey, &input)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusCreated,
+		Message: "webhook created",
+		Data:    webhook,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	webhooks, err := h.serv.GetWebhooksService(r.Context(), userCtx.UserIDKey)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "webhooks fetched",
+		Data:    webhooks,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	webhookID, err := uuid.Parse(p.ByName("webhook_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid webhook ID"))
+		return
+	}
+	if err := h.serv.DeleteWebhookService(r.Context(), userCtx.UserIDKey, webhookID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "webhook deleted",
+		Data:    nil,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	webhookID, err := uuid.Parse(p.ByName("webhook_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid webhook ID"))
+		return
+	}
+	limit, offset := pageParams(r, 20)
+	pageReq := &model.WebhookDeliveriesPageReq{
+		WebhookID: webhookID,
+		UserID:    userCtx.UserIDKey,
+		Limit:     limit,
+		Offset:    offset,
+	}
+	deliveries, err := h.serv.GetDeliveriesService(r.Context(), pageReq)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusOK,
+		Message: "webhook deliveries fetched",
+		Data:    deliveries,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request, p router.Params) {
+	userCtx, ok := r.Context().Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		writeError(w, r, errUnauthenticated)
+		return
+	}
+	webhookID, err := uuid.Parse(p.ByName("webhook_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid webhook ID"))
+		return
+	}
+	deliveryID, err := uuid.Parse(p.ByName("delivery_id"))
+	if err != nil {
+		writeError(w, r, model.Invalid("invalid delivery ID"))
+		return
+	}
+	delivery, err := h.serv.RedeliverService(r.Context(), userCtx.UserIDKey, webhookID, deliveryID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := helper.Response{
+		Status:  http.StatusAccepted,
+		Message: "webhook redelivery queued",
+		Data:    delivery,
+		Err:     nil,
+	}
+	helper.JSONResponse(w, res.Status, res)
+}
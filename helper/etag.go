@@ -0,0 +1,60 @@
+package helper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ETag builds the strong entity tag of representation, a resource at the
+// given version, as "<version>-<hash>". The hash of the JSON makes the tag
+// change with everything the body shows, such as counters and the viewer's
+// own reaction, which change without a new version.
+func ETag(version int, representation any) string {
+	body, err := json.Marshal(representation)
+	if err != nil {
+		return `"` + strconv.Itoa(version) + `"`
+	}
+	sum := sha256.Sum256(body)
+	return `"` + strconv.Itoa(version) + "-" + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// ParseIfMatch returns the strong entity tags listed in an If-Match header.
+// It returns nil when the header is absent or "*", and a non-nil empty slice
+// when no listed tag can match, since weak tags never satisfy If-Match.
+func ParseIfMatch(header string) []string {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+	tags := []string{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// NoneMatch reports whether an If-None-Match header matches etag using the
+// weak comparison, in which case a GET should answer 304 Not Modified.
+func NoneMatch(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	type item struct {
+		Version        int            `json:"version"`
+		ReactionCounts map[string]int `json:"reaction_counts"`
+		MyReaction     *string        `json:"my_reaction,omitempty"`
+	}
+	like := "like"
+	base := item{Version: 3, ReactionCounts: map[string]int{"like": 1}}
+	etag := ETag(3, base)
+	if !strings.HasPrefix(etag, `"3-`) || !strings.HasSuffix(etag, `"`) {
+		t.Fatalf("ETag = %s, want a quoted tag starting with the version", etag)
+	}
+	if again := ETag(3, item{Version: 3, ReactionCounts: map[string]int{"like": 1}}); again != etag {
+		t.Errorf("equal representations got %s and %s", etag, again)
+	}
+	for name, changed := range map[string]item{
+		"counts":      {Version: 3, ReactionCounts: map[string]int{"like": 2}},
+		"my reaction": {Version: 3, ReactionCounts: map[string]int{"like": 1}, MyReaction: &like},
+	} {
+		if ETag(3, changed) == etag {
+			t.Errorf("%s changed but the ETag did not", name)
+		}
+	}
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", nil},
+		{"*", nil},
+		{`"3-0123456789abcdef"`, []string{`"3-0123456789abcdef"`}},
+		{`"2-aa", "4-bb"`, []string{`"2-aa"`, `"4-bb"`}},
+		{`W/"3-aa"`, []string{}},
+		{`3-aa`, []string{}},
+	}
+	for _, tt := range tests {
+		if got := ParseIfMatch(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseIfMatch(%q) = %#v, want %#v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	etag := `"3-0123456789abcdef"`
+	tests := map[string]bool{
+		"":                     false,
+		"*":                    true,
+		etag:                   true,
+		"W/" + etag:            true,
+		`"1-aa", ` + etag:      true,
+		`"3-ffffffffffffffff"`: false,
+		`"3"`:                  false,
+	}
+	for header, want := range tests {
+		if got := NoneMatch(header, etag); got != want {
+			t.Errorf("NoneMatch(%q) = %t, want %t", header, got, want)
+		}
+	}
+}
@@ -1,20 +1,175 @@
 package helper
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
 
+	"github.com/bagasadiii/buy-n-con/internal/config"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var logger = logrus.New()
+var logger = newLogger()
+
+// sampleRatio is the fraction of successful requests whose access log line
+// is written.
+var sampleRatio = 1.0
+
+func newLogger() *logrus.Logger {
+	l := logrus.New()
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.AddHook(traceHook{})
+	return l
+}
+
+// ConfigureLogger applies the level, format and sampling from cfg. It is
+// called once at startup, before anything logs concurrently.
+func ConfigureLogger(cfg config.LogConfig) error {
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+	if cfg.Format == config.LogFormatText {
+		logger.SetFormatter(&logrus.TextFormatter{})
+	}
+	sampleRatio = cfg.SampleRatio
+	return nil
+}
+
+// LogWriter writes each line it is given as an info entry, for routing the
+// standard library logger through the structured one. It logs before
+// returning, so log.Fatal does not exit ahead of its message.
+func LogWriter() io.Writer {
+	return stdLogWriter{}
+}
+
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	logger.Info(strings.TrimRight(string(p), "\n"))
+	return len(p), nil
+}
+
+// requestScope is shared by everything handling one request, so the access
+// log sees the user that the auth middleware identified further down.
+type requestScope struct {
+	entry *logrus.Entry
+}
+
+type scopeKey struct{}
+
+// WithRequestLogger starts the log scope of a request. Every line logged
+// with the returned context carries request_id.
+func WithRequestLogger(ctx context.Context, requestID string) context.Context {
+	scope := &requestScope{entry: logger.WithField("request_id", requestID)}
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// SetLogUser adds user_id to the remaining lines of the request and to its
+// access log.
+func SetLogUser(ctx context.Context, userID string) {
+	if scope, ok := ctx.Value(scopeKey{}).(*requestScope); ok {
+		scope.entry = scope.entry.WithField("user_id", userID)
+	}
+}
+
+// Log is the logger of ctx: request fields when ctx belongs to a request,
+// and trace IDs when it carries a span.
+func Log(ctx context.Context) *logrus.Entry {
+	if scope, ok := ctx.Value(scopeKey{}).(*requestScope); ok {
+		return scope.entry.WithContext(ctx)
+	}
+	return logger.WithContext(ctx)
+}
+
+// AccessLog is what LogAccess writes for one request.
+type AccessLog struct {
+	Method     string
+	Route      string
+	Path       string
+	Status     int
+	DurationMS float64
+	Bytes      int64
+}
+
+// LogAccess writes the access log line of a request. Server errors are
+// always logged; other requests are sampled.
+func LogAccess(ctx context.Context, a AccessLog) {
+	if a.Status < 500 && sampleRatio < 1 && rand.Float64() >= sampleRatio {
+		return
+	}
+	entry := Log(ctx).WithFields(logrus.Fields{
+		"method":      a.Method,
+		"route":       a.Route,
+		"path":        a.Path,
+		"status":      a.Status,
+		"duration_ms": a.DurationMS,
+		"bytes":       a.Bytes,
+	})
+	if a.Status >= 500 {
+		entry.Error("request")
+		return
+	}
+	entry.Info("request")
+}
 
 func ErrMsg(err error, msg string) error {
 	if err != nil {
-		logger.Error(msg, err)
-		return errors.New(msg + err.Error())
+		logger.WithError(err).Error(logMessage(msg))
+		return fmt.Errorf("%s%w", msg, err)
+	}
+	return nil
+}
+
+// ErrMsgCtx is ErrMsg for code that has the request context. The log line
+// carries the request and trace IDs, and the error is recorded on the span.
+func ErrMsgCtx(ctx context.Context, err error, msg string) error {
+	if err != nil {
+		span := trace.SpanFromContext(ctx)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, msg)
+		Log(ctx).WithError(err).Error(logMessage(msg))
+		return fmt.Errorf("%s%w", msg, err)
+	}
+	return nil
+}
+
+// SuccessMsg logs at debug level; these lines mark routine progress, such
+// as a row being fetched.
+func SuccessMsg(msg string) {
+	logger.Debug(msg)
+}
+func SuccessMsgCtx(ctx context.Context, msg string) {
+	Log(ctx).Debug(msg)
+}
+
+// logMessage drops the ": " that messages end with from when the error was
+// appended to them, since the error is now its own field.
+func logMessage(msg string) string {
+	return strings.TrimRight(strings.TrimSpace(msg), ":")
+}
+
+// traceHook adds trace_id and span_id to entries logged with a context that
+// carries a span.
+type traceHook struct{}
+
+func (traceHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+func (traceHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	sc := trace.SpanContextFromContext(entry.Context)
+	if !sc.IsValid() {
+		return nil
 	}
+	entry.Data["trace_id"] = sc.TraceID().String()
+	entry.Data["span_id"] = sc.SpanID().String()
 	return nil
 }
-func SuccessMsg(msg string){
-	logger.Info(msg)
-}
\ No newline at end of file
@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem is an RFC 7807 problem details object, the body of every error
+// response.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the fields of a request that failed validation.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is one rule a request field broke. Field is the JSON name of
+// the field, and Param the rule's argument, such as 3 for min=3. Errors
+// built outside a request leave Message empty for the handler to translate.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// NewProblem describes a failure with status. detail is shown to clients,
+// so it must not carry internal error text.
+func NewProblem(status int, detail string) Problem {
+	return Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// WriteProblem sends p as application/problem+json, tagged with the path and
+// request ID so a client report can be matched to the logs.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Instance = r.URL.Path
+	p.RequestID = w.Header().Get("X-Request-ID")
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -2,19 +2,124 @@ package helper
 
 import (
 	"context"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/bagasadiii/buy-n-con/internal/metrics"
+	"github.com/bagasadiii/buy-n-con/internal/telemetry"
 	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func CommitOrRollback(ctx context.Context, tx pgx.Tx){
+// TxBeginner is anything transactions start from, usually *pgxpool.Pool.
+type TxBeginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// timedTx remembers when and where it began so CommitOrRollback can record
+// its duration and end its span.
+type timedTx struct {
+	pgx.Tx
+	operation string
+	start     time.Time
+	span      trace.Span
+}
+
+// BeginTx starts a transaction timed and traced under the name of the
+// calling function, such as ItemService.CreateItemService. The returned
+// context carries the transaction's span, so queries run with it nest
+// under the transaction.
+func BeginTx(ctx context.Context, db TxBeginner) (context.Context, pgx.Tx, error) {
+	operation := callerName()
+	txCtx, span := telemetry.Tracer.Start(ctx, operation)
+	tx, err := db.Begin(txCtx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return ctx, nil, err
+	}
+	return txCtx, &timedTx{Tx: tx, operation: operation, start: time.Now(), span: span}, nil
+}
+
+func CommitOrRollback(ctx context.Context, tx pgx.Tx) {
 	err := recover()
 	if err != nil {
 		rollbackErr := tx.Rollback(ctx)
+		observeTx(tx, "rollback")
 		panic(rollbackErr)
 	} else {
 		commitErr := tx.Commit(ctx)
 		if commitErr != nil {
 			ErrMsg(commitErr, "failed to commit")
+			observeTx(tx, "error")
+			return
+		}
+		observeTx(tx, "commit")
+	}
+}
+
+// EndTx is CommitOrRollback for services that write more than once: it
+// also rolls back when the function it is deferred in returns a non-nil
+// *err, so a later step failing does not keep the earlier writes. A failed
+// commit is returned through *err.
+func EndTx(ctx context.Context, tx pgx.Tx, err *error) {
+	if p := recover(); p != nil {
+		tx.Rollback(ctx)
+		observeTx(tx, "rollback")
+		panic(p)
+	}
+	if *err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+			ErrMsg(rollbackErr, "failed to rollback")
+		}
+		observeTx(tx, "rollback")
+		return
+	}
+	if commitErr := tx.Commit(ctx); commitErr != nil {
+		ErrMsg(commitErr, "failed to commit")
+		observeTx(tx, "error")
+		*err = commitErr
+		return
+	}
+	observeTx(tx, "commit")
+}
+
+func observeTx(tx pgx.Tx, outcome string) {
+	if t, ok := tx.(*timedTx); ok {
+		metrics.TxDuration.WithLabelValues(t.operation, outcome).Observe(time.Since(t.start).Seconds())
+		if outcome != "commit" {
+			t.span.SetStatus(codes.Error, outcome)
+		}
+		t.span.End()
+	}
+}
+
+var callerNames sync.Map
+
+// callerName names the function that called BeginTx, turning
+// ".../service.(*ItemService).CreateItemService" into
+// "ItemService.CreateItemService". Names are cached per call site.
+func callerName() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	if name, ok := callerNames.Load(pc); ok {
+		return name.(string)
+	}
+	name := "unknown"
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		name = fn.Name()
+		name = name[strings.LastIndex(name, "/")+1:]
+		if _, rest, ok := strings.Cut(name, "."); ok {
+			name = rest
 		}
+		name = strings.NewReplacer("(*", "", ")", "").Replace(name)
 	}
-}
\ No newline at end of file
+	callerNames.Store(pc, name)
+	return name
+}
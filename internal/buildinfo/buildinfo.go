@@ -0,0 +1,59 @@
+// Package buildinfo describes the running binary.
+package buildinfo
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Commit and BuildTime override what the Go toolchain records, for builds
+// without VCS information such as a Docker build of a plain source tree:
+//
+//	go build -ldflags "-X github.com/bagasadiii/buy-n-con/internal/buildinfo.Commit=$(git rev-parse HEAD)"
+var (
+	Commit    string
+	BuildTime string
+)
+
+type Info struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	Modified  bool   `json:"modified"`
+	GoVersion string `json:"go_version"`
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get reads the build information once. Fields the binary does not carry
+// are "unknown".
+func Get() Info {
+	once.Do(func() {
+		info = Info{Commit: Commit, BuildTime: BuildTime}
+		if bi, ok := debug.ReadBuildInfo(); ok {
+			info.GoVersion = bi.GoVersion
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					if info.Commit == "" {
+						info.Commit = s.Value
+					}
+				case "vcs.time":
+					if info.BuildTime == "" {
+						info.BuildTime = s.Value
+					}
+				case "vcs.modified":
+					info.Modified = s.Value == "true"
+				}
+			}
+		}
+		for _, field := range []*string{&info.Commit, &info.BuildTime, &info.GoVersion} {
+			if *field == "" {
+				*field = "unknown"
+			}
+		}
+	})
+	return info
+}
@@ -0,0 +1,500 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+const defaultConfigFile = ".env"
+
+type DBConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	Name     string
+}
+
+// URL is the connection string for pgx. It carries the password, so it must
+// never be logged.
+func (c DBConfig) URL() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.User, c.Password),
+		Host:   fmt.Sprintf("%s:%d", c.Host, c.Port),
+		Path:   c.Name,
+	}
+	return u.String()
+}
+
+type TokenConfig struct {
+	Secret string
+	TTL    time.Duration
+}
+
+// HTTPConfig tunes the http.Server. TLS is served when both files are set.
+type HTTPConfig struct {
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	MaxHeaderBytes  int
+	ShutdownTimeout time.Duration
+	TLSCertFile     string
+	TLSKeyFile      string
+}
+
+func (c HTTPConfig) TLS() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+// TracingConfig selects where spans go. The OTLP endpoint is read by the
+// exporter itself from OTEL_EXPORTER_OTLP_ENDPOINT and related variables.
+type TracingConfig struct {
+	Exporter    string
+	File        string
+	SampleRatio float64
+}
+
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// LogConfig sets what is logged and how. SampleRatio thins the access log of
+// successful requests; errors are always logged.
+type LogConfig struct {
+	Level       string
+	Format      string
+	SampleRatio float64
+}
+
+// Config is every setting the server reads at startup.
+type Config struct {
+	Port           int
+	HTTP           HTTPConfig
+	DB             DBConfig
+	Token          TokenConfig
+	Tracing        TracingConfig
+	Log            LogConfig
+	AllowedOrigins []string
+	TrashRetention time.Duration
+	EventRetention time.Duration
+	AutoMigrate    bool
+}
+
+func defaults() *Config {
+	return &Config{
+		Port: 8080,
+		HTTP: HTTPConfig{
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			MaxHeaderBytes:  1 << 20,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		DB:    DBConfig{Port: 5432},
+		Token: TokenConfig{TTL: 24 * time.Hour},
+		Tracing: TracingConfig{
+			Exporter:    TraceExporterNone,
+			File:        "traces.jsonl",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:       "info",
+			Format:      LogFormatJSON,
+			SampleRatio: 1,
+		},
+		AllowedOrigins: []string{"http://localhost:5173"},
+		TrashRetention: 30 * 24 * time.Hour,
+		EventRetention: 7 * 24 * time.Hour,
+		AutoMigrate:    true,
+	}
+}
+
+// setting ties one Config field to its environment key and flag. The flag
+// name is the key in lower case with dashes.
+type setting struct {
+	key    string
+	usage  string
+	secret bool
+	get    func(c *Config) string
+	set    func(c *Config, value string) error
+}
+
+func (s setting) flagName() string {
+	return strings.ToLower(strings.ReplaceAll(s.key, "_", "-"))
+}
+
+var settings = []setting{
+	{
+		key: "PORT", usage: "HTTP port",
+		get: func(c *Config) string { return strconv.Itoa(c.Port) },
+		set: func(c *Config, v string) (err error) { c.Port, err = strconv.Atoi(v); return },
+	},
+	{
+		key: "READ_TIMEOUT", usage: "longest time to read a request, body included",
+		get: func(c *Config) string { return c.HTTP.ReadTimeout.String() },
+		set: func(c *Config, v string) (err error) { c.HTTP.ReadTimeout, err = time.ParseDuration(v); return },
+	},
+	{
+		key: "WRITE_TIMEOUT", usage: "longest time to write a response; event streams are exempt",
+		get: func(c *Config) string { return c.HTTP.WriteTimeout.String() },
+		set: func(c *Config, v string) (err error) { c.HTTP.WriteTimeout, err = time.ParseDuration(v); return },
+	},
+	{
+		key: "IDLE_TIMEOUT", usage: "how long an idle keep-alive connection stays open",
+		get: func(c *Config) string { return c.HTTP.IdleTimeout.String() },
+		set: func(c *Config, v string) (err error) { c.HTTP.IdleTimeout, err = time.ParseDuration(v); return },
+	},
+	{
+		key: "MAX_HEADER_BYTES", usage: "largest request header accepted, in bytes",
+		get: func(c *Config) string { return strconv.Itoa(c.HTTP.MaxHeaderBytes) },
+		set: func(c *Config, v string) (err error) { c.HTTP.MaxHeaderBytes, err = strconv.Atoi(v); return },
+	},
+	{
+		key: "SHUTDOWN_TIMEOUT", usage: "how long to drain in-flight requests on shutdown",
+		get: func(c *Config) string { return c.HTTP.ShutdownTimeout.String() },
+		set: func(c *Config, v string) (err error) { c.HTTP.ShutdownTimeout, err = time.ParseDuration(v); return },
+	},
+	{
+		key: "TLS_CERT_FILE", usage: "PEM certificate to serve TLS with",
+		get: func(c *Config) string { return c.HTTP.TLSCertFile },
+		set: func(c *Config, v string) error { c.HTTP.TLSCertFile = v; return nil },
+	},
+	{
+		key: "TLS_KEY_FILE", usage: "PEM private key for TLS_CERT_FILE",
+		get: func(c *Config) string { return c.HTTP.TLSKeyFile },
+		set: func(c *Config, v string) error { c.HTTP.TLSKeyFile = v; return nil },
+	},
+	{
+		key: "DBHOST", usage: "database host",
+		get: func(c *Config) string { return c.DB.Host },
+		set: func(c *Config, v string) error { c.DB.Host = v; return nil },
+	},
+	{
+		key: "DBPORT", usage: "database port",
+		get: func(c *Config) string { return strconv.Itoa(c.DB.Port) },
+		set: func(c *Config, v string) (err error) { c.DB.Port, err = strconv.Atoi(v); return },
+	},
+	{
+		key: "DBUSER", usage: "database user",
+		get: func(c *Config) string { return c.DB.User },
+		set: func(c *Config, v string) error { c.DB.User = v; return nil },
+	},
+	{
+		key: "DBPASS", usage: "database password", secret: true,
+		get: func(c *Config) string { return c.DB.Password },
+		set: func(c *Config, v string) error { c.DB.Password = v; return nil },
+	},
+	{
+		key: "DBNAME", usage: "database name",
+		get: func(c *Config) string { return c.DB.Name },
+		set: func(c *Config, v string) error { c.DB.Name = v; return nil },
+	},
+	{
+		key: "SECRET_KEY", usage: "key used to sign JWTs", secret: true,
+		get: func(c *Config) string { return c.Token.Secret },
+		set: func(c *Config, v string) error { c.Token.Secret = v; return nil },
+	},
+	{
+		key: "TOKEN_TTL", usage: "lifetime of issued JWTs, e.g. 24h",
+		get: func(c *Config) string { return c.Token.TTL.String() },
+		set: func(c *Config, v string) (err error) { c.Token.TTL, err = time.ParseDuration(v); return },
+	},
+	{
+		key: "TRACE_EXPORTER", usage: "where to send traces: none, otlp, stdout or file",
+		get: func(c *Config) string { return c.Tracing.Exporter },
+		set: func(c *Config, v string) error { c.Tracing.Exporter = strings.ToLower(v); return nil },
+	},
+	{
+		key: "TRACE_FILE", usage: "file spans are appended to when TRACE_EXPORTER is file",
+		get: func(c *Config) string { return c.Tracing.File },
+		set: func(c *Config, v string) error { c.Tracing.File = v; return nil },
+	},
+	{
+		key: "TRACE_SAMPLE_RATIO", usage: "fraction of new traces recorded, from 0 to 1",
+		get: func(c *Config) string { return strconv.FormatFloat(c.Tracing.SampleRatio, 'g', -1, 64) },
+		set: func(c *Config, v string) (err error) { c.Tracing.SampleRatio, err = strconv.ParseFloat(v, 64); return },
+	},
+	{
+		key: "LOG_LEVEL", usage: "least severe level logged: debug, info, warn or error",
+		get: func(c *Config) string { return c.Log.Level },
+		set: func(c *Config, v string) error { c.Log.Level = strings.ToLower(v); return nil },
+	},
+	{
+		key: "LOG_FORMAT", usage: "log output format: json or text",
+		get: func(c *Config) string { return c.Log.Format },
+		set: func(c *Config, v string) error { c.Log.Format = strings.ToLower(v); return nil },
+	},
+	{
+		key: "LOG_SAMPLE_RATIO", usage: "fraction of successful requests written to the access log, from 0 to 1",
+		get: func(c *Config) string { return strconv.FormatFloat(c.Log.SampleRatio, 'g', -1, 64) },
+		set: func(c *Config, v string) (err error) { c.Log.SampleRatio, err = strconv.ParseFloat(v, 64); return },
+	},
+	{
+		key: "ALLOWED_ORIGINS", usage: "comma separated origins allowed by CORS and WebSockets",
+		get: func(c *Config) string { return strings.Join(c.AllowedOrigins, ",") },
+		set: func(c *Config, v string) error {
+			c.AllowedOrigins = nil
+			for _, origin := range strings.Split(v, ",") {
+				if origin = strings.TrimSpace(origin); origin != "" {
+					c.AllowedOrigins = append(c.AllowedOrigins, origin)
+				}
+			}
+			return nil
+		},
+	},
+	{
+		key: "TRASH_RETENTION_DAYS", usage: "days deleted items and posts stay in the trash",
+		get: func(c *Config) string { return strconv.Itoa(int(c.TrashRetention / (24 * time.Hour))) },
+		set: func(c *Config, v string) error {
+			days, err := strconv.Atoi(v)
+			c.TrashRetention = time.Duration(days) * 24 * time.Hour
+			return err
+		},
+	},
+	{
+		key: "EVENT_RETENTION", usage: "how long real-time events are kept for resuming, e.g. 168h",
+		get: func(c *Config) string { return c.EventRetention.String() },
+		set: func(c *Config, v string) (err error) { c.EventRetention, err = time.ParseDuration(v); return },
+	},
+	{
+		key: "AUTO_MIGRATE", usage: "apply pending migrations on start",
+		get: func(c *Config) string { return strconv.FormatBool(c.AutoMigrate) },
+		set: func(c *Config, v string) (err error) { c.AutoMigrate, err = strconv.ParseBool(v); return },
+	},
+}
+
+// Load builds the config from, in increasing priority: defaults, a dotenv
+// file, the environment and command line flags. The file is -config or
+// CONFIG_FILE, or .env if it exists. It returns the arguments left after
+// the flags, and every invalid setting at once rather than the first.
+func Load(args []string) (*Config, []string, error) {
+	cfg, rest, errs, err := load(args, func(setting) bool { return true })
+	if err != nil {
+		return nil, nil, err
+	}
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+	return cfg, rest, nil
+}
+
+// LoadDB is Load for commands that only talk to the database, such as
+// migrate. It accepts the same flags and sources but reads and checks only
+// the DB settings, so the server's secrets need not be present. The
+// arguments left after the flags are returned with invalid settings too, so
+// the caller can tell which command the errors are for.
+func LoadDB(args []string) (*DBConfig, []string, error) {
+	cfg, rest, errs, err := load(args, func(s setting) bool { return strings.HasPrefix(s.key, "DB") })
+	if err != nil {
+		return nil, nil, err
+	}
+	errs = append(errs, cfg.DB.validate()...)
+	if len(errs) > 0 {
+		return nil, rest, errors.Join(errs...)
+	}
+	return &cfg.DB, rest, nil
+}
+
+// load applies the settings that use picks on top of the defaults. It
+// returns the values that did not parse separately from errors that stop
+// loading altogether, such as an unknown flag.
+func load(args []string, use func(setting) bool) (*Config, []string, []error, error) {
+	fs := flag.NewFlagSet("buy-n-con", flag.ContinueOnError)
+	configFile := fs.String("config", "", "dotenv file to read settings from (default .env if present)")
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flags[s.key] = fs.String(s.flagName(), "", s.usage+" ("+s.key+")")
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, nil, err
+	}
+	setFlags := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+	file, err := readFile(*configFile)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cfg := defaults()
+	var errs []error
+	for _, s := range settings {
+		if !use(s) {
+			continue
+		}
+		value, ok := file[s.key]
+		if env, set := os.LookupEnv(s.key); set {
+			value, ok = env, true
+		}
+		if setFlags[s.flagName()] {
+			value, ok = *flags[s.key], true
+		}
+		if !ok {
+			continue
+		}
+		// Set on a copy so an unparsable value keeps the default and is
+		// reported once, not again by validate.
+		next := *cfg
+		if err := s.set(&next, strings.TrimSpace(value)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q", s.key, redact(s, value)))
+			continue
+		}
+		*cfg = next
+	}
+	return cfg, fs.Args(), errs, nil
+}
+
+// readFile reads the dotenv file without touching the process environment.
+// Only an explicitly named file has to exist.
+func readFile(path string) (map[string]string, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func (c DBConfig) validate() []error {
+	var errs []error
+	required := []struct {
+		key   string
+		value string
+	}{
+		{"DBHOST", c.Host},
+		{"DBUSER", c.User},
+		{"DBNAME", c.Name},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			errs = append(errs, fmt.Errorf("%s: required", r.key))
+		}
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("DBPORT: must be between 1 and 65535"))
+	}
+	return errs
+}
+
+func (c *Config) validate() []error {
+	errs := c.DB.validate()
+	if c.Token.Secret == "" {
+		errs = append(errs, fmt.Errorf("SECRET_KEY: required"))
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT: must be between 1 and 65535"))
+	}
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"READ_TIMEOUT", c.HTTP.ReadTimeout},
+		{"WRITE_TIMEOUT", c.HTTP.WriteTimeout},
+		{"IDLE_TIMEOUT", c.HTTP.IdleTimeout},
+		{"SHUTDOWN_TIMEOUT", c.HTTP.ShutdownTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive", t.key))
+		}
+	}
+	if c.HTTP.MaxHeaderBytes <= 0 {
+		errs = append(errs, fmt.Errorf("MAX_HEADER_BYTES: must be positive"))
+	}
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		errs = append(errs, fmt.Errorf("TLS_CERT_FILE, TLS_KEY_FILE: set both or neither"))
+	}
+	if c.HTTP.TLSCertFile != "" {
+		if _, err := os.Stat(c.HTTP.TLSCertFile); err != nil {
+			errs = append(errs, fmt.Errorf("TLS_CERT_FILE: %w", err))
+		}
+	}
+	if c.HTTP.TLSKeyFile != "" {
+		if _, err := os.Stat(c.HTTP.TLSKeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("TLS_KEY_FILE: %w", err))
+		}
+	}
+	if c.Token.TTL <= 0 {
+		errs = append(errs, fmt.Errorf("TOKEN_TTL: must be positive"))
+	}
+	switch c.Tracing.Exporter {
+	case TraceExporterNone, TraceExporterOTLP, TraceExporterStdout:
+	case TraceExporterFile:
+		if c.Tracing.File == "" {
+			errs = append(errs, fmt.Errorf("TRACE_FILE: required when TRACE_EXPORTER is file"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("TRACE_EXPORTER: must be none, otlp, stdout or file"))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("TRACE_SAMPLE_RATIO: must be between 0 and 1"))
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("LOG_LEVEL: must be debug, info, warn or error"))
+	}
+	if c.Log.Format != LogFormatJSON && c.Log.Format != LogFormatText {
+		errs = append(errs, fmt.Errorf("LOG_FORMAT: must be json or text"))
+	}
+	if c.Log.SampleRatio < 0 || c.Log.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("LOG_SAMPLE_RATIO: must be between 0 and 1"))
+	}
+	for _, origin := range c.AllowedOrigins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || (u.Path != "" && u.Path != "/") {
+			errs = append(errs, fmt.Errorf("ALLOWED_ORIGINS: %q is not an origin like https://example.com", origin))
+		}
+	}
+	if c.TrashRetention <= 0 {
+		errs = append(errs, fmt.Errorf("TRASH_RETENTION_DAYS: must be positive"))
+	}
+	if c.EventRetention <= 0 {
+		errs = append(errs, fmt.Errorf("EVENT_RETENTION: must be positive"))
+	}
+	return errs
+}
+
+// String lists every setting with secrets masked, so the config can be
+// logged at startup.
+func (c *Config) String() string {
+	parts := make([]string, len(settings))
+	for i, s := range settings {
+		parts[i] = s.key + "=" + redact(s, s.get(c))
+	}
+	return strings.Join(parts, " ")
+}
+
+func redact(s setting, value string) string {
+	if s.secret && value != "" {
+		return "[redacted]"
+	}
+	return value
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every setting for the test, so the loader only sees what
+// the test sets.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range append([]string{"CONFIG_FILE"}, settingKeys()...) {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+}
+
+func settingKeys() []string {
+	keys := make([]string, len(settings))
+	for i, s := range settings {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+// inTempDir runs the test in an empty directory, so no .env is read.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
+
+// withRequired returns args after flags for every required setting.
+func withRequired(args ...string) []string {
+	return append([]string{"-dbhost", "db", "-dbuser", "app", "-dbname", "shop", "-secret-key", "s3cret"}, args...)
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		args []string
+		// check looks at the loaded config; wantErrs lists the lines of the
+		// error instead, in order.
+		check    func(t *testing.T, cfg *Config, rest []string)
+		wantErrs []string
+	}{
+		{
+			name: "defaults",
+			args: withRequired(),
+			check: func(t *testing.T, cfg *Config, rest []string) {
+				want := defaults()
+				want.DB.Host, want.DB.User, want.DB.Name, want.Token.Secret = "db", "app", "shop", "s3cret"
+				if !reflect.DeepEqual(cfg, want) {
+					t.Errorf("config = %+v, want %+v", cfg, want)
+				}
+				if len(rest) != 0 {
+					t.Errorf("rest = %q", rest)
+				}
+			},
+		},
+		{
+			name:     "required",
+			wantErrs: []string{"DBHOST: required", "DBUSER: required", "DBNAME: required", "SECRET_KEY: required"},
+		},
+		{
+			name: "file, then environment, then flags",
+			file: "PORT=9000\nDBPORT=6000\nLOG_LEVEL=debug\n",
+			env:  map[string]string{"DBPORT": "6001", "LOG_LEVEL": "warn"},
+			args: withRequired("-log-level", "ERROR"),
+			check: func(t *testing.T, cfg *Config, rest []string) {
+				if cfg.Port != 9000 || cfg.DB.Port != 6001 || cfg.Log.Level != "error" {
+					t.Errorf("port %d, db port %d, log level %s", cfg.Port, cfg.DB.Port, cfg.Log.Level)
+				}
+			},
+		},
+		{
+			name: "parsed values",
+			env: map[string]string{
+				"TOKEN_TTL":            "90m",
+				"ALLOWED_ORIGINS":      " https://a.example, ,https://b.example ",
+				"TRASH_RETENTION_DAYS": "7",
+				"AUTO_MIGRATE":         "false",
+			},
+			args: withRequired("migrate", "status"),
+			check: func(t *testing.T, cfg *Config, rest []string) {
+				if cfg.Token.TTL != 90*time.Minute {
+					t.Errorf("token TTL = %s", cfg.Token.TTL)
+				}
+				if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(cfg.AllowedOrigins, want) {
+					t.Errorf("allowed origins = %q, want %q", cfg.AllowedOrigins, want)
+				}
+				if cfg.TrashRetention != 7*24*time.Hour || cfg.AutoMigrate {
+					t.Errorf("trash retention %s, auto migrate %t", cfg.TrashRetention, cfg.AutoMigrate)
+				}
+				if want := []string{"migrate", "status"}; !reflect.DeepEqual(rest, want) {
+					t.Errorf("rest = %q, want %q", rest, want)
+				}
+			},
+		},
+		{
+			name: "invalid values are all reported",
+			env: map[string]string{
+				"PORT":            "eighty",
+				"TOKEN_TTL":       "-1h",
+				"LOG_LEVEL":       "loud",
+				"ALLOWED_ORIGINS": "example.com",
+				"TLS_CERT_FILE":   "cert.pem",
+			},
+			args: []string{"-dbhost", "db", "-dbuser", "app", "-dbname", "shop"},
+			wantErrs: []string{
+				`PORT: invalid value "eighty"`,
+				"SECRET_KEY: required",
+				"TLS_CERT_FILE, TLS_KEY_FILE: set both or neither",
+				"TLS_CERT_FILE: stat cert.pem: no such file or directory",
+				"TOKEN_TTL: must be positive",
+				"LOG_LEVEL: must be debug, info, warn or error",
+				`ALLOWED_ORIGINS: "example.com" is not an origin like https://example.com`,
+			},
+		},
+		{
+			name: "out of range",
+			env: map[string]string{
+				"PORT":               "70000",
+				"DBPORT":             "0",
+				"READ_TIMEOUT":       "0s",
+				"TRACE_EXPORTER":     "file",
+				"TRACE_FILE":         "",
+				"TRACE_SAMPLE_RATIO": "1.5",
+			},
+			args: withRequired(),
+			wantErrs: []string{
+				"DBPORT: must be between 1 and 65535",
+				"PORT: must be between 1 and 65535",
+				"READ_TIMEOUT: must be positive",
+				"TRACE_FILE: required when TRACE_EXPORTER is file",
+				"TRACE_SAMPLE_RATIO: must be between 0 and 1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			inTempDir(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			args := tt.args
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "test.env")
+				if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append([]string{"-config", path}, args...)
+			}
+			cfg, rest, err := Load(args)
+			if tt.wantErrs != nil {
+				if err == nil {
+					t.Fatalf("config = %+v, want errors %q", cfg, tt.wantErrs)
+				}
+				if got := strings.Split(err.Error(), "\n"); !reflect.DeepEqual(got, tt.wantErrs) {
+					t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.wantErrs, "\n"))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, cfg, rest)
+		})
+	}
+}
+
+func TestLoadDB(t *testing.T) {
+	clearEnv(t)
+	inTempDir(t)
+	t.Setenv("PORT", "eighty")
+	t.Setenv("DBPORT", "6000")
+	db, rest, err := LoadDB([]string{"-dbhost", "db", "-dbuser", "app", "-dbname", "shop", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("server settings were checked: %v", err)
+	}
+	want := DBConfig{Host: "db", Port: 6000, User: "app", Name: "shop"}
+	if *db != want {
+		t.Errorf("db = %+v, want %+v", *db, want)
+	}
+	if len(rest) != 2 || rest[0] != "migrate" {
+		t.Errorf("rest = %q", rest)
+	}
+
+	t.Setenv("DBPORT", "0")
+	_, rest, err = LoadDB([]string{"migrate", "status"})
+	if len(rest) != 2 || rest[0] != "migrate" {
+		t.Errorf("rest with invalid settings = %q", rest)
+	}
+	wantErr := "DBHOST: required\nDBUSER: required\nDBNAME: required\nDBPORT: must be between 1 and 65535"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("err = %v, want %s", err, wantErr)
+	}
+}
+
+func TestLoadMissingConfigFile(t *testing.T) {
+	clearEnv(t)
+	inTempDir(t)
+	// A missing .env is fine, but a file that was asked for has to exist.
+	if _, _, err := Load(withRequired()); err != nil {
+		t.Fatalf("without .env: %v", err)
+	}
+	_, _, err := Load(append([]string{"-config", "missing.env"}, withRequired()...))
+	if err == nil || !strings.HasPrefix(err.Error(), "config file missing.env:") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestStringRedactsSecrets(t *testing.T) {
+	cfg := defaults()
+	cfg.DB.Password, cfg.Token.Secret = "hunter2", "s3cret"
+	s := cfg.String()
+	if strings.Contains(s, "hunter2") || strings.Contains(s, "s3cret") {
+		t.Errorf("String() leaks a secret: %s", s)
+	}
+	if !strings.Contains(s, "DBPASS=[redacted]") || !strings.Contains(s, "PORT=8080") {
+		t.Errorf("String() = %s", s)
+	}
+}
@@ -2,48 +2,36 @@ package config
 
 import (
 	"context"
-	"fmt"
 	"log"
-	"os"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func DBConnection() *pgxpool.Pool {
-	dbHost := os.Getenv("DBHOST")
-	dbUser := os.Getenv("DBUSER")
-	dbPass := os.Getenv("DBPASS")
-	dbPort := os.Getenv("DBPORT")
-	dbName := os.Getenv("DBNAME")
-
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", dbUser, dbPass, dbHost, dbPort, dbName)
-
-	if dbURL == "" {
-		log.Fatal("Database URL is not set correctly")
+// DBConnection opens the pool, retrying while the database starts. tracer,
+// if not nil, sees every query.
+func DBConnection(cfg DBConfig, tracer pgx.QueryTracer) *pgxpool.Pool {
+	poolCfg, err := pgxpool.ParseConfig(cfg.URL())
+	if err != nil {
+		log.Fatal("invalid database config: ", err)
 	}
+	poolCfg.ConnConfig.Tracer = tracer
 
 	var pool *pgxpool.Pool
-	var err error
 	for i := 0; i < 5; i++ {
-        pool, err = pgxpool.New(context.Background(), dbURL)
-        if err == nil {
-            if err = pool.Ping(context.Background()); err == nil {
-                break
-            }
-        }
-        log.Printf("Retrying database connection... (%d/5)\n", i)
-        time.Sleep(2 * time.Second)
-    }
-
-	tableQuery, err := os.ReadFile("create_table.sql")
-	if err != nil {
-		log.Fatal("failed to open schema file: ", err)
+		pool, err = pgxpool.NewWithConfig(context.Background(), poolCfg)
+		if err == nil {
+			if err = pool.Ping(context.Background()); err == nil {
+				break
+			}
+		}
+		log.Printf("Retrying database connection... (%d/5)\n", i)
+		time.Sleep(2 * time.Second)
 	}
 
-	_, err = pool.Exec(context.Background(), string(tableQuery))
 	if err != nil {
-		log.Fatal("failed to execute schema query: ", err)
+		log.Fatal("failed to connect to database: ", err)
 	}
 
 	log.Println("database connected successfully")
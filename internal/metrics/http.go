@@ -0,0 +1,13 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// ObserveHTTP records one served request. route is the pattern it was
+// registered with, so /api/u/alice and /api/u/bob share one series.
+func ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	HTTPDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+	HTTPRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+}
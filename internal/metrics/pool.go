@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector reads pgxpool.Stat on every scrape, so the values are never
+// stale and nothing polls in between.
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired        *prometheus.Desc
+	idle            *prometheus.Desc
+	constructing    *prometheus.Desc
+	total           *prometheus.Desc
+	max             *prometheus.Desc
+	acquires        *prometheus.Desc
+	emptyAcquires   *prometheus.Desc
+	canceled        *prometheus.Desc
+	acquireDuration *prometheus.Desc
+}
+
+// RegisterPool exports the connection pool statistics of pool.
+func RegisterPool(pool *pgxpool.Pool) {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	prometheus.MustRegister(&poolCollector{
+		pool:            pool,
+		acquired:        desc("acquired_conns", "Connections currently checked out."),
+		idle:            desc("idle_conns", "Connections idle in the pool."),
+		constructing:    desc("constructing_conns", "Connections being opened."),
+		total:           desc("total_conns", "Connections open, in any state."),
+		max:             desc("max_conns", "Largest number of connections the pool opens."),
+		acquires:        desc("acquires_total", "Connections acquired from the pool."),
+		emptyAcquires:   desc("empty_acquires_total", "Acquires that had to wait because no connection was idle."),
+		canceled:        desc("canceled_acquires_total", "Acquires canceled before a connection was available."),
+		acquireDuration: desc("acquire_duration_seconds_total", "Time spent waiting for connections."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(c.acquired, float64(stat.AcquiredConns()))
+	gauge(c.idle, float64(stat.IdleConns()))
+	gauge(c.constructing, float64(stat.ConstructingConns()))
+	gauge(c.total, float64(stat.TotalConns()))
+	gauge(c.max, float64(stat.MaxConns()))
+	counter(c.acquires, float64(stat.AcquireCount()))
+	counter(c.emptyAcquires, float64(stat.EmptyAcquireCount()))
+	counter(c.canceled, float64(stat.CanceledAcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+}
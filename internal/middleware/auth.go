@@ -11,36 +11,59 @@ import (
 )
 
 type ContextKey struct {
-	UserIDKey uuid.UUID
+	UserIDKey   uuid.UUID
 	UsernameKey string
 }
 type ctxKey string
+
 const UserContextKey = ctxKey("context_key")
 
-func Auth(next router.Handle)router.Handle{
+func (a *TokenAuth) Auth(next router.Handle) router.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p router.Params) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			res := helper.UnauthorizedErr("Missing Header: ", nil)
-			helper.JSONResponse(w, res.Status, res)
+			helper.WriteProblem(w, r, helper.NewProblem(http.StatusUnauthorized, "missing Authorization header"))
 			return
 		}
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		if token == "" {
-			res := helper.UnauthorizedErr("Missing token: ", nil)
-			helper.JSONResponse(w, res.Status, res)
+			helper.WriteProblem(w, r, helper.NewProblem(http.StatusUnauthorized, "missing bearer token"))
 			return
 		}
-		validation := ValidateToken(token)
-		if validation.Err != nil{
-			res := helper.UnauthorizedErr("Expired or invalid token ", validation.Err)
-			helper.JSONResponse(w, res.Status, res)
+		validation := a.ValidateToken(token)
+		if validation.Err != nil {
+			helper.Log(r.Context()).WithError(validation.Err).Debug("rejected token")
+			helper.WriteProblem(w, r, helper.NewProblem(http.StatusUnauthorized, "expired or invalid token"))
 			return
 		}
+		helper.SetLogUser(r.Context(), validation.ID.String())
 		ctx := context.WithValue(r.Context(), UserContextKey, &ContextKey{
-			UserIDKey: validation.ID,
+			UserIDKey:   validation.ID,
 			UsernameKey: validation.Username,
 		})
 		next(w, r.WithContext(ctx), p)
 	}
-}
\ No newline at end of file
+}
+
+// OptionalAuth attaches the caller's identity to the context when a valid
+// token is sent, but lets anonymous requests through untouched.
+func (a *TokenAuth) OptionalAuth(next router.Handle) router.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p router.Params) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			next(w, r, p)
+			return
+		}
+		validation := a.ValidateToken(token)
+		if validation.Err != nil {
+			next(w, r, p)
+			return
+		}
+		helper.SetLogUser(r.Context(), validation.ID.String())
+		ctx := context.WithValue(r.Context(), UserContextKey, &ContextKey{
+			UserIDKey:   validation.ID,
+			UsernameKey: validation.Username,
+		})
+		next(w, r.WithContext(ctx), p)
+	}
+}
@@ -2,15 +2,28 @@ package middleware
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/config"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-var secretKey = []byte(os.Getenv("SECRET_KEY"))
+// TokenAuth issues and checks the JWTs used for authentication. It is built
+// from the loaded config instead of reading the environment at init, so the
+// secret is always the one the server was started with.
+type TokenAuth struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenAuth(cfg config.TokenConfig) *TokenAuth {
+	return &TokenAuth{
+		secret: []byte(cfg.Secret),
+		ttl:    cfg.TTL,
+	}
+}
 
 type Claims struct {
 	ID       uuid.UUID
@@ -18,22 +31,23 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 type UserValidation struct {
-	Token		string
-	ID			uuid.UUID
-	Username 	string
-	Err 		error
+	Token    string
+	ID       uuid.UUID
+	Username string
+	Err      error
 }
-func GenerateToken(id uuid.UUID, username string) (string, error) {
-	exp := time.Now().Add(24 * time.Hour)
-	newClaims := &Claims {
-		ID: id,
+
+func (a *TokenAuth) GenerateToken(id uuid.UUID, username string) (string, error) {
+	exp := time.Now().Add(a.ttl)
+	newClaims := &Claims{
+		ID:       id,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(exp),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256,newClaims)
-	tokenString, err := token.SignedString(secretKey)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims)
+	tokenString, err := token.SignedString(a.secret)
 	if err != nil {
 		helper.ErrMsg(err, "failed to generate token")
 		return "", err
@@ -42,30 +56,30 @@ func GenerateToken(id uuid.UUID, username string) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateToken(tokenString string)*UserValidation{
+func (a *TokenAuth) ValidateToken(tokenString string) *UserValidation {
 	newClaims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, newClaims, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
 		}
-		return []byte(secretKey), nil
+		return a.secret, nil
 	})
 	if err != nil {
 		helper.ErrMsg(err, "failed to validate token")
 		return &UserValidation{
 			Token: tokenString,
-			Err: err,
+			Err:   err,
 		}
 	}
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
 		return &UserValidation{
-			Token: tokenString,
+			Token:    tokenString,
 			Username: claims.Username,
-			ID: claims.ID,
+			ID:       claims.ID,
 		}
 	}
 	return &UserValidation{
 		Token: tokenString,
 		Err:   errors.New("invalid token"),
 	}
-}
\ No newline at end of file
+}
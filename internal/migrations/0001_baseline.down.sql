@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS
+    webhook_deliveries,
+    webhooks,
+    user_events,
+    notification_preferences,
+    blocks,
+    messages,
+    conversation_members,
+    conversations,
+    notifications,
+    post_mentions,
+    post_tags,
+    post_items,
+    follows,
+    item_reactions,
+    post_reactions,
+    comments,
+    item_revisions,
+    posts,
+    items,
+    users
+CASCADE;
@@ -0,0 +1,313 @@
+-- Baseline: the schema as create_table.sql built it on every boot. It keeps
+-- IF NOT EXISTS so databases created that way are adopted as they are.
+CREATE TABLE IF NOT EXISTS users (
+    user_id UUID PRIMARY KEY,
+    username VARCHAR(30) UNIQUE NOT NULL,
+    email VARCHAR(30) UNIQUE NOT NULL,
+    password text NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE IF NOT EXISTS items (
+    item_id UUID PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    quantity INT NOT NULL,
+    price INT NOT NULL,
+    description text,
+    owner VARCHAR(30) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    user_id UUID,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE SET NULL
+);
+CREATE TABLE IF NOT EXISTS posts (
+    post_id UUID PRIMARY KEY,
+    content TEXT NOT NULL,
+    owner varchar(50) not null,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    user_id UUID,
+    CONSTRAINT fk_users
+        FOREIGN KEY(user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE SET NULL
+);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'active';
+CREATE INDEX IF NOT EXISTS idx_items_owner_status ON items (owner, status, created_at DESC);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_items_deleted_at ON items (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts (deleted_at) WHERE deleted_at IS NOT NULL;
+ALTER TABLE items ADD COLUMN IF NOT EXISTS previous_price INT;
+CREATE TABLE IF NOT EXISTS item_revisions (
+    revision_id UUID PRIMARY KEY,
+    item_id UUID NOT NULL,
+    user_id UUID,
+    changed_by VARCHAR(30) NOT NULL,
+    field VARCHAR(20) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    changed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_items
+        FOREIGN KEY (item_id)
+        REFERENCES items (item_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_item_revisions_item ON item_revisions (item_id, changed_at DESC);
+ALTER TABLE items ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS comment_count INT NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS comments (
+    comment_id UUID PRIMARY KEY,
+    post_id UUID NOT NULL,
+    parent_id UUID,
+    depth INT NOT NULL DEFAULT 0,
+    content TEXT NOT NULL,
+    owner VARCHAR(30) NOT NULL,
+    reply_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMPTZ,
+    user_id UUID,
+    CONSTRAINT fk_posts
+        FOREIGN KEY (post_id)
+        REFERENCES posts (post_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_parent
+        FOREIGN KEY (parent_id)
+        REFERENCES comments (comment_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_comments_post_parent ON comments (post_id, parent_id, created_at);
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS reaction_counts JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE items ADD COLUMN IF NOT EXISTS reaction_counts JSONB NOT NULL DEFAULT '{}';
+CREATE TABLE IF NOT EXISTS post_reactions (
+    post_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    reaction VARCHAR(10) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id),
+    CONSTRAINT fk_posts
+        FOREIGN KEY (post_id)
+        REFERENCES posts (post_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS item_reactions (
+    item_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    reaction VARCHAR(10) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (item_id, user_id),
+    CONSTRAINT fk_items
+        FOREIGN KEY (item_id)
+        REFERENCES items (item_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS follower_count INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS following_count INT NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id UUID NOT NULL,
+    followee_id UUID NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT fk_follower
+        FOREIGN KEY (follower_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_followee
+        FOREIGN KEY (followee_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_follows_followee ON follows (followee_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_posts_feed ON posts (user_id, created_at DESC, post_id DESC) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_items_feed ON items (user_id, created_at DESC, item_id DESC) WHERE deleted_at IS NULL;
+CREATE TABLE IF NOT EXISTS post_items (
+    post_id UUID NOT NULL,
+    item_id UUID NOT NULL,
+    position INT NOT NULL,
+    PRIMARY KEY (post_id, item_id),
+    CONSTRAINT fk_posts
+        FOREIGN KEY (post_id)
+        REFERENCES posts (post_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_items
+        FOREIGN KEY (item_id)
+        REFERENCES items (item_id)
+        ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id UUID NOT NULL,
+    tag VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, tag),
+    CONSTRAINT fk_posts
+        FOREIGN KEY (post_id)
+        REFERENCES posts (post_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag ON post_tags (tag, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_post_tags_created ON post_tags (created_at);
+CREATE TABLE IF NOT EXISTS post_mentions (
+    post_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    PRIMARY KEY (post_id, user_id),
+    CONSTRAINT fk_posts
+        FOREIGN KEY (post_id)
+        REFERENCES posts (post_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS notifications (
+    notification_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, created_at DESC);
+CREATE TABLE IF NOT EXISTS conversations (
+    conversation_id UUID PRIMARY KEY,
+    user_a UUID NOT NULL,
+    user_b UUID NOT NULL,
+    item_id UUID,
+    last_message_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user_a
+        FOREIGN KEY (user_a)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_user_b
+        FOREIGN KEY (user_b)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversations_pair
+    ON conversations (user_a, user_b, COALESCE(item_id, '00000000-0000-0000-0000-000000000000'::uuid));
+CREATE TABLE IF NOT EXISTS conversation_members (
+    conversation_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    unread_count INT NOT NULL DEFAULT 0,
+    last_read_at TIMESTAMPTZ,
+    PRIMARY KEY (conversation_id, user_id),
+    CONSTRAINT fk_conversations
+        FOREIGN KEY (conversation_id)
+        REFERENCES conversations (conversation_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_conversation_members_user ON conversation_members (user_id);
+CREATE TABLE IF NOT EXISTS messages (
+    message_id UUID PRIMARY KEY,
+    conversation_id UUID NOT NULL,
+    sender_id UUID NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_conversations
+        FOREIGN KEY (conversation_id)
+        REFERENCES conversations (conversation_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_users
+        FOREIGN KEY (sender_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages (conversation_id, created_at DESC);
+CREATE TABLE IF NOT EXISTS blocks (
+    blocker_id UUID NOT NULL,
+    blocked_id UUID NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT fk_blocker
+        FOREIGN KEY (blocker_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_blocked
+        FOREIGN KEY (blocked_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS channel VARCHAR(10) NOT NULL DEFAULT 'in_app';
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID NOT NULL,
+    type VARCHAR(30) NOT NULL,
+    channel VARCHAR(10) NOT NULL,
+    PRIMARY KEY (user_id, type),
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS user_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_user_events_user ON user_events (user_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_user_events_created ON user_events (created_at);
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    events TEXT[] NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks (user_id);
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id UUID PRIMARY KEY,
+    webhook_id UUID NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ,
+    last_status_code INT,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    delivered_at TIMESTAMPTZ,
+    CONSTRAINT fk_webhooks
+        FOREIGN KEY (webhook_id)
+        REFERENCES webhooks (webhook_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC);
@@ -0,0 +1,8 @@
+-- Restoring the cascade drops the history of items purged since.
+DELETE FROM item_revisions r
+WHERE NOT EXISTS (SELECT 1 FROM items i WHERE i.item_id = r.item_id);
+ALTER TABLE item_revisions
+    ADD CONSTRAINT fk_items
+        FOREIGN KEY (item_id)
+        REFERENCES items (item_id)
+        ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Item history is kept for disputes after the item itself is purged from
+-- the trash, so revisions no longer cascade with their item. item_id still
+-- names the purged item.
+ALTER TABLE item_revisions DROP CONSTRAINT IF EXISTS fk_items;
@@ -0,0 +1,12 @@
+-- Single-use tickets that browsers open WebSocket and SSE streams with,
+-- instead of putting the access token in the URL. Only a hash is stored.
+CREATE TABLE IF NOT EXISTS stream_tickets (
+    ticket_hash BYTEA PRIMARY KEY,
+    user_id UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_users
+        FOREIGN KEY (user_id)
+        REFERENCES "users" (user_id)
+        ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_stream_tickets_expires ON stream_tickets (expires_at);
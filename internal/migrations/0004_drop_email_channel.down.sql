@@ -0,0 +1,2 @@
+-- Which preferences were email is not kept; they stay in_app.
+SELECT 1;
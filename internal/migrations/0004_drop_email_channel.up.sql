@@ -0,0 +1,4 @@
+-- Nothing ever sent email, so the email channel is gone. Those notifications
+-- were listed in-app all along.
+UPDATE notification_preferences SET channel = 'in_app' WHERE channel = 'email';
+UPDATE notifications SET channel = 'in_app' WHERE channel = 'email';
@@ -0,0 +1,6 @@
+-- order.placed and review.created were accepted but never sent. Drop them
+-- from subscriptions, and drop webhooks that were left with no events.
+UPDATE webhooks
+SET events = array_remove(array_remove(events, 'order.placed'), 'review.created')
+WHERE events && ARRAY['order.placed', 'review.created'];
+DELETE FROM webhooks WHERE cardinality(events) = 0;
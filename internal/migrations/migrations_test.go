@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for i, m := range migrations {
+		if want := int64(i + 1); m.Version != want {
+			t.Errorf("migration %d_%s: want version %d, versions must not skip", m.Version, m.Name, want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(body string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(body)} }
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{"ordered", fstest.MapFS{
+			"0002_b.up.sql":   file("B"),
+			"0002_b.down.sql": file("-B"),
+			"0001_a.up.sql":   file("A"),
+			"0001_a.down.sql": file("-A"),
+			"README.md":       file("ignored"),
+		}, ""},
+		{"missing down", fstest.MapFS{"0001_a.up.sql": file("A")}, "needs both up and down files"},
+		{"mismatched names", fstest.MapFS{
+			"0001_a.up.sql":   file("A"),
+			"0001_b.down.sql": file("-B"),
+		}, "differ"},
+		{"no version", fstest.MapFS{"baseline.up.sql": file("A")}, "expected <version>_<name>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != 2 || migrations[0].Name != "a" || migrations[1].Up != "B" || migrations[1].Down != "-B" {
+				t.Errorf("migrations = %+v", migrations)
+			}
+		})
+	}
+}
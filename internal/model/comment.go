@@ -0,0 +1,95 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// MaxCommentDepth is how many levels a thread may have, top-level comments
+// being depth 0.
+const MaxCommentDepth = 3
+
+var (
+	ErrCommentTooDeep   = Invalid("comment thread is too deep to reply to")
+	ErrCommentForbidden = Forbidden("not allowed to modify this comment")
+)
+
+type Comment struct {
+	CommentID  uuid.UUID  `json:"comment_id"`
+	PostID     uuid.UUID  `json:"post_id"`
+	ParentID   *uuid.UUID `json:"parent_id"`
+	Depth      int        `json:"depth"`
+	Content    string     `json:"content"`
+	Owner      string     `json:"owner"`
+	UserID     uuid.UUID  `json:"user_id"`
+	ReplyCount int        `json:"reply_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+type CommentInput struct {
+	Content  string     `json:"content" validate:"required,max=2000"`
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+type UpdateCommentInput struct {
+	Content string `json:"content" validate:"required,max=2000"`
+}
+type GetCommentInput struct {
+	CommentID uuid.UUID `json:"comment_id"`
+	PostID    uuid.UUID `json:"post_id"`
+	PostOwner string    `json:"post_owner"`
+	Requester string    `json:"-"`
+}
+type CommentsPageReq struct {
+	PostID    uuid.UUID  `json:"post_id"`
+	PostOwner string     `json:"post_owner"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+}
+type CommentsPageRes struct {
+	Comments      []Comment `json:"comments"`
+	TotalComments int       `json:"total_comments"`
+	TotalPages    int       `json:"total_pages"`
+	Current       int       `json:"current"`
+	PageSize      int       `json:"page_size"`
+}
+
+func NewComment(ctx context.Context, input *CommentInput, postID uuid.UUID, parent *Comment) (*Comment, error) {
+	ctxKey, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		helper.ErrMsg(nil, "failed to get context key: ")
+		return nil, Unauthenticated("unauthorized")
+	}
+	if ctxKey.UserIDKey == uuid.Nil || ctxKey.UsernameKey == "" {
+		helper.ErrMsg(nil, "no data in context")
+		return nil, Unauthenticated("unauthorized")
+	}
+	content := strings.TrimSpace(input.Content)
+	if content == "" {
+		return nil, Invalid("content cannot be empty")
+	}
+	depth := 0
+	if parent != nil {
+		depth = parent.Depth + 1
+		if depth >= MaxCommentDepth {
+			return nil, ErrCommentTooDeep
+		}
+	}
+	return &Comment{
+		CommentID: uuid.New(),
+		PostID:    postID,
+		ParentID:  input.ParentID,
+		Depth:     depth,
+		Content:   content,
+		Owner:     ctxKey.UsernameKey,
+		UserID:    ctxKey.UserIDKey,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+)
+
+// Kinds of failure a service reports. Handlers map them to HTTP statuses;
+// an error wrapping none of them is an internal error.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrValidation      = errors.New("validation failed")
+	ErrForbidden       = errors.New("forbidden")
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+// Error is a failure of a known kind whose Detail is written for clients,
+// so it can be shown to them as is. Err is an optional cause kept for logs.
+// A validation error may also name the fields that broke a rule.
+type Error struct {
+	Kind   error
+	Detail string
+	Fields []helper.FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Detail + ": " + e.Err.Error()
+	}
+	return e.Detail
+}
+
+// Unwrap exposes both the kind and the cause to errors.Is and errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+	return []error{e.Kind}
+}
+
+func NotFound(detail string) *Error {
+	return &Error{Kind: ErrNotFound, Detail: detail}
+}
+
+func Conflict(detail string) *Error {
+	return &Error{Kind: ErrConflict, Detail: detail}
+}
+
+func Invalid(detail string) *Error {
+	return &Error{Kind: ErrValidation, Detail: detail}
+}
+
+// InvalidFields reports a request whose fields break the given rules.
+func InvalidFields(fields ...helper.FieldError) *Error {
+	return &Error{Kind: ErrValidation, Detail: "request failed validation", Fields: fields}
+}
+
+func Forbidden(detail string) *Error {
+	return &Error{Kind: ErrForbidden, Detail: detail}
+}
+
+func Unauthenticated(detail string) *Error {
+	return &Error{Kind: ErrUnauthenticated, Detail: detail}
+}
+
+// Wrap returns a copy of e that also carries cause, for logging.
+func (e *Error) Wrap(cause error) *Error {
+	return &Error{Kind: e.Kind, Detail: e.Detail, Fields: e.Fields, Err: cause}
+}
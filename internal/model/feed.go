@@ -0,0 +1,85 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidCursor = Invalid("invalid feed cursor")
+
+type FeedEntryType string
+
+const (
+	FeedEntryPost FeedEntryType = "post"
+	FeedEntryItem FeedEntryType = "item"
+)
+
+// FeedEntry is either a post or a new item listing; exactly one of Post and
+// Item is set, matching Type.
+type FeedEntry struct {
+	Type      FeedEntryType `json:"type"`
+	CreatedAt time.Time     `json:"created_at"`
+	Post      *Post         `json:"post,omitempty"`
+	Item      *ItemResp     `json:"item,omitempty"`
+}
+
+// FeedCursor points just past the last entry of a page. Entries are ordered
+// by (created_at, id) descending so ties on created_at still page cleanly.
+type FeedCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+type FeedReq struct {
+	UserID uuid.UUID
+	Limit  int
+	Cursor *FeedCursor
+}
+type FeedRes struct {
+	Entries    []FeedEntry `json:"entries"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+func (e *FeedEntry) ID() uuid.UUID {
+	if e.Post != nil {
+		return e.Post.PostID
+	}
+	return e.Item.ItemID
+}
+
+// Before reports whether e sorts ahead of other in the feed.
+func (e *FeedEntry) Before(other *FeedEntry) bool {
+	if !e.CreatedAt.Equal(other.CreatedAt) {
+		return e.CreatedAt.After(other.CreatedAt)
+	}
+	a, b := e.ID(), other.ID()
+	return bytes.Compare(a[:], b[:]) > 0
+}
+
+func (c *FeedCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseFeedCursor(s string) (*FeedCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &FeedCursor{CreatedAt: t, ID: parsedID}, nil
+}
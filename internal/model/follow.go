@@ -0,0 +1,56 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/google/uuid"
+)
+
+var ErrFollowSelf = Invalid("cannot follow yourself")
+
+type Follow struct {
+	FollowerID uuid.UUID
+	Follower   string
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+// FollowUser is one entry of a follower or following list.
+type FollowUser struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+type FollowsPageReq struct {
+	Username string `json:"username"`
+	Limit    int    `json:"limit"`
+	Offset   int    `json:"offset"`
+}
+type FollowsPageRes struct {
+	Users      []FollowUser `json:"users"`
+	TotalUsers int          `json:"total_users"`
+	TotalPages int          `json:"total_pages"`
+	Current    int          `json:"current"`
+	PageSize   int          `json:"page_size"`
+}
+
+func NewFollow(ctx context.Context, followeeID uuid.UUID) (*Follow, error) {
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		err := Unauthenticated("unauthorized")
+		helper.ErrMsg(err, "user id not found in context")
+		return nil, err
+	}
+	if userCtx.UserIDKey == followeeID {
+		return nil, ErrFollowSelf
+	}
+	return &Follow{
+		FollowerID: userCtx.UserIDKey,
+		Follower:   userCtx.UsernameKey,
+		FolloweeID: followeeID,
+		CreatedAt:  time.Now(),
+	}, nil
+}
@@ -2,7 +2,6 @@ package model
 
 import (
 	"context"
-	"errors"
 	"strings"
 	"time"
 
@@ -12,76 +11,120 @@ import (
 )
 
 type Item struct {
-	ItemID    		uuid.UUID		`json:"item_id"`
-	UserID    		uuid.UUID		`json:"user_id"`
-	Owner	 		string			`json:"owner"`
-	Name      		string			`json:"name"`
-	Quantity  		int				`json:"quantity"`
-	Price     		int				`json:"price"`
-	Description		string			`json:"description"`
-	CreatedAt 		time.Time		`json:"created_at"`
-	UpdatedAt 		time.Time		`json:"updated_at"`
+	ItemID      uuid.UUID  `json:"item_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Owner       string     `json:"owner"`
+	Name        string     `json:"name"`
+	Quantity    int        `json:"quantity"`
+	Price       int        `json:"price"`
+	Description string     `json:"description"`
+	Status      ItemStatus `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int        `json:"version"`
 }
 type CreateItemInput struct {
-	ItemID			uuid.UUID		`json:"item_id"`
-	Name      		string			`json:"name" validate:"required"`
-	Quantity  		int				`json:"quantity" validate:"required,gt=0"`
-	Price     		int				`json:"price" validate:"required,gt=0"`
-	Description		string			`json:"description"`
+	ItemID      uuid.UUID  `json:"item_id"`
+	Name        string     `json:"name" validate:"required"`
+	Quantity    int        `json:"quantity" validate:"required,gt=0"`
+	Price       int        `json:"price" validate:"required,gt=0"`
+	Description string     `json:"description"`
+	Status      ItemStatus `json:"status" validate:"omitempty,oneof=draft active"`
 }
 type GetItemInput struct {
-	ItemID			uuid.UUID		`json:"item_id"`
-	Owner			string			`json:"owner"`
+	ItemID   uuid.UUID `json:"item_id"`
+	Owner    string    `json:"owner"`
+	Viewer   string    `json:"-"`
+	ViewerID uuid.UUID `json:"-"`
+	IfMatch  []string  `json:"-"`
+}
+type UpdateItemStatusInput struct {
+	Status ItemStatus `json:"status" validate:"required,oneof=draft active reserved sold archived"`
 }
 type UpdateItemInput struct {
-	Name      string    `json:"name" validate:"required,min=3"`
-	Quantity  int       `json:"quantity" validate:"required,gt=0"`
-	Price     int       `json:"price" validate:"required,gt=0"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Description	string	`json:"description"`
+	Name        Optional[string] `json:"name" validate:"omitnil,min=3"`
+	Quantity    Optional[int]    `json:"quantity" validate:"omitnil,gte=0"`
+	Price       Optional[int]    `json:"price" validate:"omitnil,gt=0"`
+	Description Optional[string] `json:"description"`
 }
 type ItemResp struct {
-	ItemID    uuid.UUID `json:"item_id"`
-	Owner	string	`json:"owner"`
-	Name      string    `json:"name"`
-	Quantity  int       `json:"quantity"`
-	Price     int       `json:"price"`
-	Description	string		`json:"description"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ItemID           uuid.UUID        `json:"item_id"`
+	Owner            string           `json:"owner"`
+	Name             string           `json:"name"`
+	Quantity         int              `json:"quantity"`
+	Price            int              `json:"price"`
+	PriceDroppedFrom *int             `json:"price_dropped_from,omitempty"`
+	Description      string           `json:"description"`
+	Status           ItemStatus       `json:"status"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+	Version          int              `json:"version"`
+	ReactionCounts   map[Reaction]int `json:"reaction_counts"`
+	MyReaction       *Reaction        `json:"my_reaction,omitempty"`
+	DeletedAt        *time.Time       `json:"deleted_at,omitempty"`
 }
 type ItemsPageReq struct {
-	Username	string	`json:"username"`
-	Limit		int		`json:"limit"`
-	Offset		int		`json:"offset"`
+	Username string     `json:"username"`
+	Limit    int        `json:"limit"`
+	Offset   int        `json:"offset"`
+	Status   ItemStatus `json:"status"`
+	Viewer   string     `json:"-"`
+	ViewerID uuid.UUID  `json:"-"`
 }
 type ItemsPageRes struct {
-	Items		[]ItemResp			`json:"items"`
-	TotalItems	int					`json:"total_items"`
-	TotalPages 	int					`json:"total_pages"`
-	Current		int					`json:"current"`
-	PageSize	int					`json:"page_size"`
+	Items      []ItemResp `json:"items"`
+	TotalItems int        `json:"total_items"`
+	TotalPages int        `json:"total_pages"`
+	Current    int        `json:"current"`
+	PageSize   int        `json:"page_size"`
 }
-func NewItem(ctx context.Context, input *CreateItemInput)(*Item, error){
+
+func NewItem(ctx context.Context, input *CreateItemInput) (*Item, error) {
 	name := strings.TrimSpace(input.Name)
 	ctxKey, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
 	if !ok {
 		helper.ErrMsg(nil, "failed to get context key: ")
-		return nil, errors.New("failed to get context key")
+		return nil, Unauthenticated("unauthorized")
 	}
-	if ctxKey.UserIDKey == uuid.Nil || ctxKey.UsernameKey == ""{
+	if ctxKey.UserIDKey == uuid.Nil || ctxKey.UsernameKey == "" {
 		helper.ErrMsg(nil, "no data in context")
-		return nil, errors.New("no data in context")
+		return nil, Unauthenticated("unauthorized")
+	}
+	status := input.Status
+	if status == "" {
+		status = ItemStatusActive
 	}
 	return &Item{
-		ItemID: uuid.New(),
-		UserID: ctxKey.UserIDKey,
-		Owner: ctxKey.UsernameKey,
-		Name: name,
-		Quantity: input.Quantity,
-		Price: input.Price,
+		ItemID:      uuid.New(),
+		UserID:      ctxKey.UserIDKey,
+		Owner:       ctxKey.UsernameKey,
+		Name:        name,
+		Quantity:    input.Quantity,
+		Price:       input.Price,
 		Description: input.Description,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Status:      status,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		Version:     1,
 	}, nil
-}
\ No newline at end of file
+}
+
+// Apply merges the patch into item. Fields left out keep their value, a null
+// description clears it and a null on any other field is rejected. Stock
+// that runs out or comes back moves the status along, see StockChanged.
+func (in *UpdateItemInput) Apply(item *ItemResp) error {
+	if err := applyRequired(in.Name, &item.Name, "name"); err != nil {
+		return err
+	}
+	stock := item.Quantity
+	if err := applyRequired(in.Quantity, &item.Quantity, "quantity"); err != nil {
+		return err
+	}
+	item.Status = item.Status.StockChanged(stock, item.Quantity)
+	if err := applyRequired(in.Price, &item.Price, "price"); err != nil {
+		return err
+	}
+	applyNullable(in.Description, &item.Description)
+	item.Name = strings.TrimSpace(item.Name)
+	return nil
+}
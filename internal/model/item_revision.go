@@ -0,0 +1,65 @@
+package model
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/google/uuid"
+)
+
+type ItemRevision struct {
+	RevisionID uuid.UUID `json:"revision_id"`
+	ItemID     uuid.UUID `json:"item_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	ChangedBy  string    `json:"changed_by"`
+	Field      string    `json:"field"`
+	OldValue   *string   `json:"old_value"`
+	NewValue   *string   `json:"new_value"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+type ItemHistoryReq struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+}
+type ItemHistoryRes struct {
+	Revisions      []ItemRevision `json:"revisions"`
+	TotalRevisions int            `json:"total_revisions"`
+	TotalPages     int            `json:"total_pages"`
+	Current        int            `json:"current"`
+	PageSize       int            `json:"page_size"`
+}
+
+// NewItemRevisions compares an item before and after a change and returns
+// one revision per field that differs, attributed to the user in ctx.
+func NewItemRevisions(ctx context.Context, before, after *ItemResp) ([]ItemRevision, error) {
+	ctxKey, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		return nil, Unauthenticated("unauthorized")
+	}
+	now := time.Now()
+	var revisions []ItemRevision
+	add := func(field, oldValue, newValue string) {
+		if oldValue == newValue {
+			return
+		}
+		revisions = append(revisions, ItemRevision{
+			RevisionID: uuid.New(),
+			ItemID:     after.ItemID,
+			UserID:     ctxKey.UserIDKey,
+			ChangedBy:  ctxKey.UsernameKey,
+			Field:      field,
+			OldValue:   &oldValue,
+			NewValue:   &newValue,
+			ChangedAt:  now,
+		})
+	}
+	add("name", before.Name, after.Name)
+	add("quantity", strconv.Itoa(before.Quantity), strconv.Itoa(after.Quantity))
+	add("price", strconv.Itoa(before.Price), strconv.Itoa(after.Price))
+	add("description", before.Description, after.Description)
+	add("status", string(before.Status), string(after.Status))
+	return revisions, nil
+}
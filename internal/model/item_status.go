@@ -0,0 +1,56 @@
+package model
+
+type ItemStatus string
+
+const (
+	ItemStatusDraft    ItemStatus = "draft"
+	ItemStatusActive   ItemStatus = "active"
+	ItemStatusReserved ItemStatus = "reserved"
+	ItemStatusSold     ItemStatus = "sold"
+	ItemStatusArchived ItemStatus = "archived"
+)
+
+var ErrInvalidStatusTransition = Conflict("invalid item status transition")
+
+// itemTransitions lists the statuses an item may move to from each status.
+var itemTransitions = map[ItemStatus][]ItemStatus{
+	ItemStatusDraft:    {ItemStatusActive, ItemStatusArchived},
+	ItemStatusActive:   {ItemStatusDraft, ItemStatusReserved, ItemStatusSold, ItemStatusArchived},
+	ItemStatusReserved: {ItemStatusActive, ItemStatusSold},
+	ItemStatusSold:     {ItemStatusArchived},
+	ItemStatusArchived: {ItemStatusDraft, ItemStatusActive},
+}
+
+func (s ItemStatus) Valid() bool {
+	_, ok := itemTransitions[s]
+	return ok
+}
+
+// Public reports whether items in this status are visible to everyone.
+// Drafts and archived items are only shown to their owner.
+func (s ItemStatus) Public() bool {
+	return s == ItemStatusActive || s == ItemStatusReserved || s == ItemStatusSold
+}
+
+func (s ItemStatus) CanTransitionTo(next ItemStatus) bool {
+	for _, allowed := range itemTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// StockChanged returns the status an item in status s moves to when its
+// quantity goes from before to after: a listed item whose last unit is gone
+// is sold, and a sold out item that is restocked is listed again. Drafts and
+// archived items keep their status, as they are not for sale either way.
+func (s ItemStatus) StockChanged(before, after int) ItemStatus {
+	switch {
+	case before > 0 && after == 0 && (s == ItemStatusActive || s == ItemStatusReserved):
+		return ItemStatusSold
+	case before == 0 && after > 0 && s == ItemStatusSold:
+		return ItemStatusActive
+	}
+	return s
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMessageSelf           = Invalid("cannot message yourself")
+	ErrMessageBlocked        = Forbidden("messaging between these users is blocked")
+	ErrBlockSelf             = Invalid("cannot block yourself")
+	ErrItemNotInConversation = Invalid("item must belong to one of the participants")
+)
+
+type ConversationPeer struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+}
+
+// Conversation is a 1:1 thread as seen by one of its two members. UnreadCount
+// and PeerReadAt are from that member's point of view.
+type Conversation struct {
+	ConversationID uuid.UUID        `json:"conversation_id"`
+	ItemID         *uuid.UUID       `json:"item_id"`
+	Peer           ConversationPeer `json:"peer"`
+	UnreadCount    int              `json:"unread_count"`
+	PeerReadAt     *time.Time       `json:"peer_read_at"`
+	LastMessageAt  *time.Time       `json:"last_message_at"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+type ConversationInput struct {
+	Username string     `json:"username" validate:"required"`
+	ItemID   *uuid.UUID `json:"item_id"`
+}
+type ConversationsPageReq struct {
+	UserID uuid.UUID `json:"-"`
+	Limit  int       `json:"limit"`
+	Offset int       `json:"offset"`
+}
+type ConversationsPageRes struct {
+	Conversations      []Conversation `json:"conversations"`
+	TotalUnread        int            `json:"total_unread"`
+	TotalConversations int            `json:"total_conversations"`
+	TotalPages         int            `json:"total_pages"`
+	Current            int            `json:"current"`
+	PageSize           int            `json:"page_size"`
+}
+
+// Message is a single direct message. Read is set once the recipient has
+// opened the conversation after the message was sent.
+type Message struct {
+	MessageID      uuid.UUID `json:"message_id"`
+	ConversationID uuid.UUID `json:"conversation_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Content        string    `json:"content"`
+	Read           bool      `json:"read"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+type MessageInput struct {
+	Content string `json:"content" validate:"required,max=4000"`
+}
+type MessagesPageReq struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	UserID         uuid.UUID `json:"-"`
+	Limit          int       `json:"limit"`
+	Offset         int       `json:"offset"`
+}
+type MessagesPageRes struct {
+	Messages      []Message `json:"messages"`
+	TotalMessages int       `json:"total_messages"`
+	TotalPages    int       `json:"total_pages"`
+	Current       int       `json:"current"`
+	PageSize      int       `json:"page_size"`
+}
+
+func NewMessage(ctx context.Context, input *MessageInput, conversationID uuid.UUID) (*Message, error) {
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		err := Unauthenticated("unauthorized")
+		helper.ErrMsg(err, "user id not found in context")
+		return nil, err
+	}
+	content := strings.TrimSpace(input.Content)
+	if content == "" {
+		return nil, Invalid("message cannot be empty")
+	}
+	return &Message{
+		MessageID:      uuid.New(),
+		ConversationID: conversationID,
+		SenderID:       userCtx.UserIDKey,
+		Content:        content,
+		CreatedAt:      time.Now(),
+	}, nil
+}
+
+// ConversationMembers orders two user IDs the way conversations store them,
+// so each pair (and item) maps to exactly one row.
+func ConversationMembers(a, b uuid.UUID) (uuid.UUID, uuid.UUID) {
+	if strings.Compare(a.String(), b.String()) > 0 {
+		return b, a
+	}
+	return a, b
+}
@@ -0,0 +1,173 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidNotificationPreference = Invalid("invalid notification preference")
+
+type NotificationType string
+
+const (
+	NotificationFollow       NotificationType = "follow"
+	NotificationComment      NotificationType = "comment"
+	NotificationMention      NotificationType = "mention"
+	NotificationOrderShipped NotificationType = "order_shipped"
+	NotificationOffer        NotificationType = "offer"
+	NotificationPriceDrop    NotificationType = "price_drop"
+)
+
+// NotificationTypes lists every type a user can set a preference for.
+var NotificationTypes = []NotificationType{
+	NotificationFollow,
+	NotificationComment,
+	NotificationMention,
+	NotificationOrderShipped,
+	NotificationOffer,
+	NotificationPriceDrop,
+}
+
+func (t NotificationType) Valid() bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationChannel is how a user wants to hear about one type of event.
+// Nothing sends email, so there is no email channel until something does.
+type NotificationChannel string
+
+const (
+	ChannelInApp NotificationChannel = "in_app"
+	ChannelOff   NotificationChannel = "off"
+)
+
+func (c NotificationChannel) Valid() bool {
+	return c == ChannelInApp || c == ChannelOff
+}
+
+// NotificationEvent is the typed payload of a notification. Each payload
+// knows its own type, so callers cannot pair a payload with the wrong one.
+type NotificationEvent interface {
+	NotificationType() NotificationType
+}
+
+type FollowPayload struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	Follower   string    `json:"follower"`
+}
+
+// CommentPayload is sent to the post owner, or to the parent comment's author
+// when ParentID is set.
+type CommentPayload struct {
+	PostID    uuid.UUID  `json:"post_id"`
+	PostOwner string     `json:"post_owner"`
+	CommentID uuid.UUID  `json:"comment_id"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty"`
+	Commenter string     `json:"commenter"`
+}
+
+type MentionPayload struct {
+	PostID    uuid.UUID `json:"post_id"`
+	PostOwner string    `json:"post_owner"`
+}
+
+type OrderShippedPayload struct {
+	OrderID        uuid.UUID `json:"order_id"`
+	ItemID         uuid.UUID `json:"item_id"`
+	TrackingNumber string    `json:"tracking_number,omitempty"`
+}
+
+type OfferPayload struct {
+	OfferID uuid.UUID `json:"offer_id"`
+	ItemID  uuid.UUID `json:"item_id"`
+	Buyer   string    `json:"buyer"`
+	Amount  int       `json:"amount"`
+}
+
+type PriceDropPayload struct {
+	ItemID   uuid.UUID `json:"item_id"`
+	Owner    string    `json:"owner"`
+	Name     string    `json:"name"`
+	OldPrice int       `json:"old_price"`
+	NewPrice int       `json:"new_price"`
+}
+
+func (FollowPayload) NotificationType() NotificationType       { return NotificationFollow }
+func (CommentPayload) NotificationType() NotificationType      { return NotificationComment }
+func (MentionPayload) NotificationType() NotificationType      { return NotificationMention }
+func (OrderShippedPayload) NotificationType() NotificationType { return NotificationOrderShipped }
+func (OfferPayload) NotificationType() NotificationType        { return NotificationOffer }
+func (PriceDropPayload) NotificationType() NotificationType    { return NotificationPriceDrop }
+
+type Notification struct {
+	NotificationID uuid.UUID           `json:"notification_id"`
+	UserID         uuid.UUID           `json:"user_id"`
+	Type           NotificationType    `json:"type"`
+	Channel        NotificationChannel `json:"channel"`
+	Payload        json.RawMessage     `json:"payload"`
+	ReadAt         *time.Time          `json:"read_at"`
+	CreatedAt      time.Time           `json:"created_at"`
+}
+type NotificationsPageReq struct {
+	UserID     uuid.UUID `json:"-"`
+	UnreadOnly bool      `json:"unread_only"`
+	Limit      int       `json:"limit"`
+	Offset     int       `json:"offset"`
+}
+type NotificationsPageRes struct {
+	Notifications      []Notification `json:"notifications"`
+	TotalUnread        int            `json:"total_unread"`
+	TotalNotifications int            `json:"total_notifications"`
+	TotalPages         int            `json:"total_pages"`
+	Current            int            `json:"current"`
+	PageSize           int            `json:"page_size"`
+}
+
+// NotificationPreferences maps each type to its channel. Types a user never
+// changed are in-app.
+type NotificationPreferences map[NotificationType]NotificationChannel
+
+func DefaultNotificationPreferences() NotificationPreferences {
+	prefs := make(NotificationPreferences, len(NotificationTypes))
+	for _, kind := range NotificationTypes {
+		prefs[kind] = ChannelInApp
+	}
+	return prefs
+}
+
+// Validate rejects unknown types and channels, so a typo does not silently
+// leave a preference unchanged.
+func (p NotificationPreferences) Validate() error {
+	for kind, channel := range p {
+		if !kind.Valid() {
+			return fmt.Errorf("%w: unknown type %q", ErrInvalidNotificationPreference, kind)
+		}
+		if !channel.Valid() {
+			return fmt.Errorf("%w: unknown channel %q", ErrInvalidNotificationPreference, channel)
+		}
+	}
+	return nil
+}
+
+func NewNotification(userID uuid.UUID, event NotificationEvent, channel NotificationChannel) (*Notification, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return &Notification{
+		NotificationID: uuid.New(),
+		UserID:         userID,
+		Type:           event.NotificationType(),
+		Channel:        channel,
+		Payload:        raw,
+		CreatedAt:      time.Now(),
+	}, nil
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+var ErrNullField = Invalid("field cannot be null")
+
+// Optional is a field of a JSON Merge Patch (RFC 7396) body. It tells a
+// field that was left out apart from one sent as null or with a value.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+// FieldValue exposes the sent value to the validator, or a nil pointer when
+// the field was left out or null so that "omitnil" rules skip it.
+func (o Optional[T]) FieldValue() any {
+	if !o.Set || o.Null {
+		return (*T)(nil)
+	}
+	return &o.Value
+}
+
+// applyRequired merges a field that may not be cleared.
+func applyRequired[T any](o Optional[T], dst *T, name string) error {
+	if !o.Set {
+		return nil
+	}
+	if o.Null {
+		return fmt.Errorf("%w: %s", ErrNullField, name)
+	}
+	*dst = o.Value
+	return nil
+}
+
+// applyNullable merges a field where null clears the stored value.
+func applyNullable[T any](o Optional[T], dst *T) {
+	if !o.Set {
+		return
+	}
+	var zero T
+	if o.Null {
+		*dst = zero
+		return
+	}
+	*dst = o.Value
+}
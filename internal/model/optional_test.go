@@ -0,0 +1,87 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateItemInputApply(t *testing.T) {
+	stored := ItemResp{Name: "Lamp", Quantity: 2, Price: 100, Description: "Brass"}
+	tests := []struct {
+		name    string
+		patch   string
+		want    ItemResp
+		wantErr error
+	}{
+		{"empty patch keeps everything", `{}`, stored, nil},
+		{"sent fields replace", `{"price": 80, "name": "  Desk lamp "}`, ItemResp{Name: "Desk lamp", Quantity: 2, Price: 80, Description: "Brass"}, nil},
+		{"null clears a nullable field", `{"description": null}`, ItemResp{Name: "Lamp", Quantity: 2, Price: 100}, nil},
+		{"zero is a value, not a removal", `{"quantity": 0}`, ItemResp{Name: "Lamp", Price: 100, Description: "Brass"}, nil},
+		{"null on a required field", `{"name": null}`, stored, ErrNullField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch UpdateItemInput
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+			item := stored
+			err := patch.Apply(&item)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Apply = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(item, tt.want) {
+				t.Errorf("item = %+v, want %+v", item, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateItemInputApplyStatus(t *testing.T) {
+	tests := []struct {
+		status   ItemStatus
+		quantity int
+		patch    string
+		want     ItemStatus
+	}{
+		{ItemStatusActive, 2, `{"quantity": 0}`, ItemStatusSold},
+		{ItemStatusReserved, 1, `{"quantity": 0}`, ItemStatusSold},
+		{ItemStatusActive, 2, `{"quantity": 5}`, ItemStatusActive},
+		{ItemStatusSold, 0, `{"quantity": 3}`, ItemStatusActive},
+		{ItemStatusSold, 2, `{"quantity": 3}`, ItemStatusSold},
+		{ItemStatusSold, 0, `{"price": 50}`, ItemStatusSold},
+		{ItemStatusDraft, 2, `{"quantity": 0}`, ItemStatusDraft},
+		{ItemStatusArchived, 0, `{"quantity": 4}`, ItemStatusArchived},
+	}
+	for _, tt := range tests {
+		var patch UpdateItemInput
+		if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+			t.Fatal(err)
+		}
+		item := ItemResp{Name: "Lamp", Quantity: tt.quantity, Price: 100, Status: tt.status}
+		if err := patch.Apply(&item); err != nil {
+			t.Fatal(err)
+		}
+		if item.Status != tt.want {
+			t.Errorf("%s item with %d left, patch %s: status %s, want %s", tt.status, tt.quantity, tt.patch, item.Status, tt.want)
+		}
+	}
+}
+
+func TestOptionalFieldValue(t *testing.T) {
+	var patch UpdateItemInput
+	if err := json.Unmarshal([]byte(`{"name": "Lamp", "description": null}`), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := patch.Name.FieldValue().(*string); !ok || v == nil || *v != "Lamp" {
+		t.Errorf("sent field: FieldValue = %v", patch.Name.FieldValue())
+	}
+	if v := patch.Description.FieldValue().(*string); v != nil {
+		t.Errorf("null field: FieldValue = %v, want nil", *v)
+	}
+	if v := patch.Price.FieldValue().(*int); v != nil {
+		t.Errorf("absent field: FieldValue = %v, want nil", *v)
+	}
+}
@@ -2,7 +2,6 @@ package model
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/bagasadiii/buy-n-con/helper"
@@ -11,57 +10,82 @@ import (
 )
 
 type Post struct {
-	PostID		uuid.UUID		`json:"post_id"`
-	Content		string			`json:"content"`
-	Owner		string			`json:"owner"`
-	CreatedAt	time.Time		`json:"created_at"`
-	UpdatedAt	time.Time		`json:"updated_at"`
-	UserID		uuid.UUID		`json:"user_id"`
+	PostID         uuid.UUID        `json:"post_id"`
+	Content        string           `json:"content"`
+	Owner          string           `json:"owner"`
+	CreatedAt      time.Time        `json:"created_at"`
+	UpdatedAt      time.Time        `json:"updated_at"`
+	UserID         uuid.UUID        `json:"user_id"`
+	Version        int              `json:"version"`
+	CommentCount   int              `json:"comment_count"`
+	ReactionCounts map[Reaction]int `json:"reaction_counts"`
+	MyReaction     *Reaction        `json:"my_reaction,omitempty"`
+	ItemIDs        []uuid.UUID      `json:"-"`
+	Items          []ItemCard       `json:"items,omitempty"`
+	DeletedAt      *time.Time       `json:"deleted_at,omitempty"`
 }
 type PostInput struct {
-	Content		string			`json:"content" validate:"required"`
+	Content string      `json:"content" validate:"required"`
+	ItemIDs []uuid.UUID `json:"item_ids" validate:"max=10"`
 }
 type GetPostInput struct {
-	PostID		uuid.UUID		`json:"post_id"`
-	Owner		string			`json:"owner"`
+	PostID   uuid.UUID `json:"post_id"`
+	Owner    string    `json:"owner"`
+	ViewerID uuid.UUID `json:"-"`
+	IfMatch  []string  `json:"-"`
 }
 type UpdatePostInput struct {
-	PostID		uuid.UUID		`json:"post_id"`
-	Owner		string			`json:"owner"`
-	Content		string			`json:"content" validate:"required"`
-	UpdatedAt	time.Time		`json:"updated_at"`
+	Content Optional[string]      `json:"content" validate:"omitnil,min=1"`
+	ItemIDs Optional[[]uuid.UUID] `json:"item_ids" validate:"omitnil,max=10"`
 }
 type PostsPageReq struct {
-	Username	string			`json:"username"`
-	Limit		int				`json:"limit"`
-	Offset		int				`json:"offset"`
+	Username string    `json:"username"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	ViewerID uuid.UUID `json:"-"`
 }
 type PostsPageRes struct {
-	Posts		[]Post			`json:"posts"`
-	TotalPosts	int				`json:"total_posts"`
-	TotalPages 	int				`json:"total_pages"`
-	Current		int				`json:"current"`
-	PageSize	int				`json:"page_size"`
+	Posts      []Post `json:"posts"`
+	TotalPosts int    `json:"total_posts"`
+	TotalPages int    `json:"total_pages"`
+	Current    int    `json:"current"`
+	PageSize   int    `json:"page_size"`
 }
-func NewPost (ctx context.Context, input *PostInput)(*Post, error){
+
+func NewPost(ctx context.Context, input *PostInput) (*Post, error) {
 	ctxKey, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
 	if !ok {
 		helper.ErrMsg(nil, "failed to get context key: ")
-		return nil, errors.New("failed to get context key")
+		return nil, Unauthenticated("unauthorized")
 	}
-	if ctxKey.UserIDKey == uuid.Nil || ctxKey.UsernameKey == ""{
+	if ctxKey.UserIDKey == uuid.Nil || ctxKey.UsernameKey == "" {
 		helper.ErrMsg(nil, "no data in context")
-		return nil, errors.New("no data in context")
+		return nil, Unauthenticated("unauthorized")
 	}
 	if input.Content == "" {
-		return nil, errors.New("content cannot be empty")
+		return nil, Invalid("content cannot be empty")
 	}
 	return &Post{
-		PostID: uuid.New(),
-		Content: input.Content,
-		Owner: ctxKey.UsernameKey,
+		PostID:    uuid.New(),
+		Content:   input.Content,
+		Owner:     ctxKey.UsernameKey,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		UserID: ctxKey.UserIDKey,
+		UserID:    ctxKey.UserIDKey,
+		Version:   1,
+		ItemIDs:   uniqueItemIDs(input.ItemIDs),
 	}, nil
-}
\ No newline at end of file
+}
+
+// Apply merges the patch into post. Content cannot be cleared, a null
+// item_ids detaches every item. ItemIDs stays nil when attachments are left
+// untouched.
+func (in *UpdatePostInput) Apply(post *Post) error {
+	if err := applyRequired(in.Content, &post.Content, "content"); err != nil {
+		return err
+	}
+	if in.ItemIDs.Set {
+		post.ItemIDs = uniqueItemIDs(in.ItemIDs.Value)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/google/uuid"
+)
+
+// ErrItemNotAttachable reports an item_ids entry that is not one of the
+// author's own public items.
+var ErrItemNotAttachable = InvalidFields(helper.FieldError{Field: "item_ids", Rule: "attachable"})
+
+// ItemCard is an item embedded in a post. Price, stock and status are read
+// live from the item, so a card always shows the current listing. Once the
+// item is deleted or taken out of the public listing only ItemID is kept and
+// Available is false.
+type ItemCard struct {
+	ItemID    uuid.UUID  `json:"item_id"`
+	Name      string     `json:"name,omitempty"`
+	Price     int        `json:"price,omitempty"`
+	Quantity  int        `json:"quantity"`
+	Status    ItemStatus `json:"status,omitempty"`
+	Available bool       `json:"available"`
+	Removed   bool       `json:"removed,omitempty"`
+}
+
+// NewItemCard builds the card for an attached item. Sold or reserved items
+// keep their details but are not available; deleted, draft and archived
+// items are shown as removed.
+func NewItemCard(item *ItemResp, deleted bool) ItemCard {
+	if deleted || !item.Status.Public() {
+		return ItemCard{ItemID: item.ItemID, Removed: true}
+	}
+	return ItemCard{
+		ItemID:    item.ItemID,
+		Name:      item.Name,
+		Price:     item.Price,
+		Quantity:  item.Quantity,
+		Status:    item.Status,
+		Available: item.Status == ItemStatusActive && item.Quantity > 0,
+	}
+}
+
+// uniqueItemIDs drops repeated IDs while keeping the order they were sent in.
+func uniqueItemIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	res := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		res = append(res, id)
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/google/uuid"
+)
+
+// Reaction is one of the fixed emoji a user can leave on a post or item.
+type Reaction string
+
+const (
+	ReactionLike  Reaction = "like"
+	ReactionLove  Reaction = "love"
+	ReactionLaugh Reaction = "laugh"
+	ReactionWow   Reaction = "wow"
+	ReactionSad   Reaction = "sad"
+)
+
+// ReactionTarget names the kind of resource a reaction belongs to.
+type ReactionTarget string
+
+const (
+	ReactionTargetPost ReactionTarget = "post"
+	ReactionTargetItem ReactionTarget = "item"
+)
+
+type ReactionInput struct {
+	Reaction Reaction `json:"reaction" validate:"required,oneof=like love laugh wow sad"`
+}
+
+// ReactionRecord is a single user's reaction on a post or item.
+type ReactionRecord struct {
+	Target    ReactionTarget
+	TargetID  uuid.UUID
+	UserID    uuid.UUID
+	Reaction  Reaction
+	CreatedAt time.Time
+}
+
+// ReactionResp is what the caller sees after reacting: their own reaction,
+// if any is left after the toggle, and the new totals for the target.
+type ReactionResp struct {
+	TargetID       uuid.UUID        `json:"target_id"`
+	MyReaction     *Reaction        `json:"my_reaction"`
+	ReactionCounts map[Reaction]int `json:"reaction_counts"`
+}
+
+func NewReactionRecord(ctx context.Context, target ReactionTarget, targetID uuid.UUID, reaction Reaction) (*ReactionRecord, error) {
+	userCtx, ok := ctx.Value(middleware.UserContextKey).(*middleware.ContextKey)
+	if !ok {
+		err := Unauthenticated("unauthorized")
+		helper.ErrMsg(err, "user id not found in context")
+		return nil, err
+	}
+	return &ReactionRecord{
+		Target:    target,
+		TargetID:  targetID,
+		UserID:    userCtx.UserIDKey,
+		Reaction:  reaction,
+		CreatedAt: time.Now(),
+	}, nil
+}
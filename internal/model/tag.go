@@ -0,0 +1,55 @@
+package model
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_#])#([\p{L}\p{N}_]{1,50})`)
+	mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_]{3,30})`)
+)
+
+type TagPostsPageReq struct {
+	Tag      string    `json:"tag"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+	ViewerID uuid.UUID `json:"-"`
+}
+type TrendingTag struct {
+	Tag       string `json:"tag"`
+	PostCount int    `json:"post_count"`
+}
+type TrendingTagsReq struct {
+	Since time.Time
+	Limit int
+}
+
+// ParseTags returns the distinct hashtags and mentioned usernames in content,
+// lowercased and in the order they first appear. Usernames cannot contain
+// uppercase letters, so "@Alice" still mentions "alice".
+func ParseTags(content string) (tags []string, mentions []string) {
+	return matchDistinct(hashtagPattern, content), matchDistinct(mentionPattern, content)
+}
+
+// NormalizeTag lowercases a tag taken from a URL and drops a leading '#'.
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(tag, "#"))
+}
+
+func matchDistinct(pattern *regexp.Regexp, content string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+		value := strings.ToLower(match[1])
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		res = append(res, value)
+	}
+	return res
+}
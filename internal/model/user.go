@@ -1,56 +1,66 @@
 package model
 
 import (
-	"errors"
 	"time"
-	"unicode"
 
 	"github.com/bagasadiii/buy-n-con/helper"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrInvalidCredentials is returned for an unknown username and a wrong
+// password alike, so a login does not reveal which accounts exist.
+var ErrInvalidCredentials = Unauthenticated("invalid username or password")
+
+// ErrInvalidUsername reports a username that breaks ValidUsername, in the
+// same shape as the request validator's errors. Handlers fill in the
+// message in the client's language.
+var ErrInvalidUsername = InvalidFields(helper.FieldError{Field: "username", Rule: "username"})
+
 type User struct {
-	UserID		uuid.UUID	`json:"user_id"`
-	Username	string		`json:"username"`
-	Email		string		`json:"email"`
-	Password	string		`json:"password"`
-	CreatedAt	time.Time	`json:"created_at"`
-	UpdatedAt	time.Time	`json:"updated_at"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Password  string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type RegisterInput struct {
-	Username string		`json:"username" validate:"required,min=3"`
-	Email    string		`json:"email" validate:"required,email"`
-	Password string		`json:"password" validate:"required,min=6"`
+	Username string `json:"username" validate:"required,min=3,username"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
 }
 
 type LoginInput struct {
-	Username string		`json:"username" validate:"required,min=3"`
-	Password string		`json:"password" validate:"required,min=6"`
+	Username string `json:"username" validate:"required,min=3"`
+	Password string `json:"password" validate:"required,min=6"`
 }
 
 type UserResponse struct {
-	UserID		uuid.UUID	`json:"user_id"`
-	Username	string		`json:"username"`
-	Email		string		`json:"email"`
-	CreatedAt	time.Time	`json:"created_at"`
-	UpdatedAt	time.Time	`json:"updated_at"`
+	UserID         uuid.UUID `json:"user_id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	FollowerCount  int       `json:"follower_count"`
+	FollowingCount int       `json:"following_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
-func NewUser(input *RegisterInput)(*User, error){
-	for _, char := range input.Username {
-		if unicode.IsUpper(char) {
-			return nil, errors.New("username cannot contain uppercase")
-		}
-		
-		if unicode.IsSpace(char) {
-			return nil, errors.New("username cannot contain spaces")
-		}
-		
-		if !(unicode.IsLetter(char) || unicode.IsDigit(char) || char == '_') {
-			return nil, errors.New("username can only contain letters, digits, and underscores")
+
+// ValidUsername reports whether username uses only the ASCII lowercase
+// letters a to z, digits and underscores.
+func ValidUsername(username string) bool {
+	for _, char := range username {
+		if !(char >= 'a' && char <= 'z' || char >= '0' && char <= '9' || char == '_') {
+			return false
 		}
 	}
+	return true
+}
+func NewUser(input *RegisterInput) (*User, error) {
+	if !ValidUsername(input.Username) {
+		return nil, ErrInvalidUsername
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -58,11 +68,11 @@ func NewUser(input *RegisterInput)(*User, error){
 		return nil, err
 	}
 	return &User{
-		UserID: uuid.New(),
-		Username: input.Username,
-		Email: input.Email,
-		Password: string(hashedPassword),
+		UserID:    uuid.New(),
+		Username:  input.Username,
+		Email:     input.Email,
+		Password:  string(hashedPassword),
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}, nil
-}
\ No newline at end of file
+}
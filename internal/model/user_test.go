@@ -0,0 +1,20 @@
+package model
+
+import "testing"
+
+func TestValidUsername(t *testing.T) {
+	tests := map[string]bool{
+		"bob":       true,
+		"bob_42":    true,
+		"Bob":       false,
+		"josé":      false,
+		"straße":    false,
+		"bob-smith": false,
+		"bob smith": false,
+	}
+	for username, want := range tests {
+		if got := ValidUsername(username); got != want {
+			t.Errorf("ValidUsername(%q) = %t, want %t", username, got, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"errors"
+	"slices"
+)
+
+var (
+	ErrPreconditionFailed = errors.New("resource does not match the requested version")
+	ErrVersionConflict    = Conflict("resource was modified by another request")
+)
+
+// CheckETag enforces an If-Match precondition against the current ETag of
+// the resource, comparing whole tags as RFC 7232 asks. A nil ifMatch means
+// the client sent none, an empty one means none of its tags can ever match.
+func CheckETag(ifMatch []string, current string) error {
+	if ifMatch == nil || slices.Contains(ifMatch, current) {
+		return nil
+	}
+	return ErrPreconditionFailed
+}
+
+// VersionError turns a write that lost the race on its version guard into
+// a failed precondition when the request carried If-Match: the tag the
+// client matched is gone. Without If-Match it stays ErrVersionConflict.
+func VersionError(ifMatch []string, err error) error {
+	if ifMatch != nil && errors.Is(err, ErrVersionConflict) {
+		return ErrPreconditionFailed
+	}
+	return err
+}
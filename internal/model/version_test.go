@@ -0,0 +1,51 @@
+package model
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+)
+
+func TestCheckETag(t *testing.T) {
+	current := helper.ETag(4, "body")
+	tests := []struct {
+		ifMatch string
+		want    error
+	}{
+		{"", nil},
+		{"*", nil},
+		{current, nil},
+		{`"3-aa", ` + current, nil},
+		{helper.ETag(4, "other body"), ErrPreconditionFailed},
+		{helper.ETag(3, "body"), ErrPreconditionFailed},
+		{`"4-aa"`, ErrPreconditionFailed},
+		{`"4"`, ErrPreconditionFailed},
+		{"W/" + current, ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		if err := CheckETag(helper.ParseIfMatch(tt.ifMatch), current); !errors.Is(err, tt.want) {
+			t.Errorf("If-Match %s against %s: %v, want %v", tt.ifMatch, current, err, tt.want)
+		}
+	}
+}
+
+func TestVersionError(t *testing.T) {
+	other := errors.New("boom")
+	tests := []struct {
+		ifMatch []string
+		err     error
+		want    error
+	}{
+		{nil, ErrVersionConflict, ErrVersionConflict},
+		{[]string{`"4-aa"`}, ErrVersionConflict, ErrPreconditionFailed},
+		{[]string{}, ErrVersionConflict, ErrPreconditionFailed},
+		{[]string{`"4-aa"`}, other, other},
+		{[]string{`"4-aa"`}, nil, nil},
+	}
+	for _, tt := range tests {
+		if err := VersionError(tt.ifMatch, tt.err); !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("VersionError(%q, %v) = %v, want %v", tt.ifMatch, tt.err, err, tt.want)
+		}
+	}
+}
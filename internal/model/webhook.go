@@ -0,0 +1,171 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/google/uuid"
+)
+
+type WebhookEvent string
+
+// Only events something dispatches are listed, so nobody subscribes to an
+// event that never fires.
+const (
+	WebhookItemSoldOut WebhookEvent = "item.sold_out"
+)
+
+// ErrWebhookURL reports a webhook URL that is not http(s) or that reaches a
+// loopback, private or link-local address, which the worker must not call.
+var ErrWebhookURL = InvalidFields(helper.FieldError{Field: "url", Rule: "public_url"})
+
+var (
+	ErrWebhookSignature        = errors.New("webhook signature does not match")
+	ErrWebhookSignatureExpired = errors.New("webhook signature is too old")
+)
+
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook is a user's subscription to some event types. Secret is only
+// returned when the webhook is created.
+type Webhook struct {
+	WebhookID uuid.UUID      `json:"webhook_id"`
+	UserID    uuid.UUID      `json:"user_id"`
+	URL       string         `json:"url"`
+	Events    []WebhookEvent `json:"events"`
+	Secret    string         `json:"secret,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+type WebhookInput struct {
+	URL    string         `json:"url" validate:"required,url,max=500"`
+	Events []WebhookEvent `json:"events" validate:"required,min=1,dive,oneof=item.sold_out"`
+}
+
+// WebhookPayload is the body POSTed to the subscriber. EventID stays the same
+// when a delivery is redelivered, so receivers can drop duplicates.
+type WebhookPayload struct {
+	EventID   uuid.UUID       `json:"event_id"`
+	Event     WebhookEvent    `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery is one queued or attempted POST of a payload to a webhook.
+type WebhookDelivery struct {
+	DeliveryID     uuid.UUID             `json:"delivery_id"`
+	WebhookID      uuid.UUID             `json:"webhook_id"`
+	Event          WebhookEvent          `json:"event"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at"`
+	LastStatusCode *int                  `json:"last_status_code"`
+	LastError      *string               `json:"last_error"`
+	CreatedAt      time.Time             `json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at"`
+	// URL and Secret are filled in for the worker only.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+type WebhookDeliveriesPageReq struct {
+	WebhookID uuid.UUID `json:"webhook_id"`
+	UserID    uuid.UUID `json:"-"`
+	Limit     int       `json:"limit"`
+	Offset    int       `json:"offset"`
+}
+type WebhookDeliveriesPageRes struct {
+	Deliveries      []WebhookDelivery `json:"deliveries"`
+	TotalDeliveries int               `json:"total_deliveries"`
+	TotalPages      int               `json:"total_pages"`
+	Current         int               `json:"current"`
+	PageSize        int               `json:"page_size"`
+}
+
+func NewWebhook(userID uuid.UUID, input *WebhookInput) (*Webhook, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return &Webhook{
+		WebhookID: uuid.New(),
+		UserID:    userID,
+		URL:       input.URL,
+		Events:    input.Events,
+		Secret:    hex.EncodeToString(secret),
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// NewWebhookDelivery queues payload for webhookID, due right away.
+func NewWebhookDelivery(webhookID uuid.UUID, payload *WebhookPayload) (*WebhookDelivery, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &WebhookDelivery{
+		DeliveryID:    uuid.New(),
+		WebhookID:     webhookID,
+		Event:         payload.Event,
+		Payload:       raw,
+		Status:        DeliveryPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}, nil
+}
+
+// SignWebhook returns the value of the X-Webhook-Signature header for a body
+// sent at t: "t=<unix seconds>,sha256=<hex>", where the hex is the
+// HMAC-SHA256 of "<unix seconds>.<body>" keyed with the webhook's secret.
+// Signing the time lets receivers refuse a captured delivery replayed later.
+func SignWebhook(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + timestamp + ",sha256=" + webhookMAC(secret, timestamp, body)
+}
+
+// VerifyWebhook checks a signature made by SignWebhook, and that it was made
+// no more than tolerance before now.
+func VerifyWebhook(secret, signature string, body []byte, now time.Time, tolerance time.Duration) error {
+	var timestamp, mac string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "sha256":
+			mac = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || mac == "" {
+		return ErrWebhookSignature
+	}
+	if !hmac.Equal([]byte(mac), []byte(webhookMAC(secret, timestamp, body))) {
+		return ErrWebhookSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrWebhookSignatureExpired
+	}
+	return nil
+}
+
+func webhookMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"item.sold_out"}`)
+	sent := time.Unix(1700000000, 0)
+	signature := SignWebhook("secret", sent, body)
+	if !strings.HasPrefix(signature, "t=1700000000,sha256=") {
+		t.Fatalf("signature = %s", signature)
+	}
+	if signature == SignWebhook("secret", sent.Add(time.Second), body) {
+		t.Error("signature does not depend on the time")
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		body      string
+		now       time.Time
+		want      error
+	}{
+		{"valid", "secret", signature, string(body), sent.Add(time.Minute), nil},
+		{"wrong secret", "other", signature, string(body), sent, ErrWebhookSignature},
+		{"tampered body", "secret", signature, `{"event":"item.sold_out","data":{}}`, sent, ErrWebhookSignature},
+		{"replayed", "secret", signature, string(body), sent.Add(time.Hour), ErrWebhookSignatureExpired},
+		{"moved timestamp", "secret", strings.Replace(signature, "t=1700000000", "t=1700003600", 1), string(body), sent.Add(time.Hour), ErrWebhookSignature},
+		{"no timestamp", "secret", signature[strings.Index(signature, ",")+1:], string(body), sent, ErrWebhookSignature},
+		{"empty", "secret", "", string(body), sent, ErrWebhookSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhook(tt.secret, tt.signature, []byte(tt.body), tt.now, 5*time.Minute)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyWebhook = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+// Package openapi builds the OpenAPI 3.1 document of the API from a table
+// of operations and the Go types they exchange, and checks that table
+// against the routes actually registered.
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/google/uuid"
+)
+
+// Auth is how an operation authenticates its caller.
+type Auth int
+
+const (
+	AuthNone Auth = iota
+	// AuthRequired operations answer 401 without a valid bearer token.
+	AuthRequired
+	// AuthOptional operations serve anyone, but show more to the owner.
+	AuthOptional
+)
+
+// Param is a path or query parameter. Value is any value of its type, such
+// as 0 for an integer; nil means a string.
+type Param struct {
+	Name        string
+	Description string
+	Value       any
+	Required    bool
+}
+
+// Operation documents one route. Body and Data are values of the request
+// body and of the data in the response envelope; nil means there is none.
+// Operations answering something other than the JSON envelope set Content
+// to its media type instead.
+type Operation struct {
+	Method     string
+	Path       string
+	Tag        string
+	Summary    string
+	Auth       Auth
+	PathParams []Param
+	Query      []Param
+	Body       any
+	BodyType   string
+	Status     int
+	Data       any
+	Content    string
+}
+
+// Info names the API in the document.
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+}
+
+// Build returns the OpenAPI document of ops as JSON.
+func Build(info Info, ops []Operation) ([]byte, error) {
+	s := newSchemas()
+	envelope := s.of(reflect.TypeFor[helper.Response]())
+	problem := s.of(reflect.TypeFor[helper.Problem]())
+
+	paths := map[string]Schema{}
+	for _, op := range ops {
+		path, params := pathParams(s, op)
+		item, ok := paths[path]
+		if !ok {
+			item = Schema{}
+			paths[path] = item
+		}
+		operation := Schema{
+			"operationId": operationID(op),
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+			"responses":   responses(s, op, envelope, problem),
+		}
+		for _, q := range op.Query {
+			params = append(params, parameter(s, "query", q))
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Body != nil {
+			bodyType := op.BodyType
+			if bodyType == "" {
+				bodyType = "application/json"
+			}
+			operation["requestBody"] = Schema{
+				"required": true,
+				"content":  Schema{bodyType: Schema{"schema": s.of(reflect.TypeOf(op.Body))}},
+			}
+		}
+		switch op.Auth {
+		case AuthRequired:
+			operation["security"] = []Schema{{"bearerAuth": []string{}}}
+		case AuthOptional:
+			operation["security"] = []Schema{{}, {"bearerAuth": []string{}}}
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return json.MarshalIndent(Schema{
+		"openapi": "3.1.0",
+		"info": Schema{
+			"title":       info.Title,
+			"version":     info.Version,
+			"description": info.Description,
+		},
+		"paths": paths,
+		"components": Schema{
+			"schemas": s.components,
+			"securitySchemes": Schema{
+				"bearerAuth": Schema{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}, "", "  ")
+}
+
+func responses(s *schemas, op Operation, envelope, problem Schema) Schema {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := Schema{"description": http.StatusText(status)}
+	switch {
+	case status == http.StatusSwitchingProtocols:
+		// The connection leaves HTTP; there is no body to describe.
+	case op.Content != "":
+		success["content"] = Schema{op.Content: Schema{}}
+	default:
+		body := envelope
+		if op.Data != nil {
+			body = Schema{"allOf": []Schema{envelope, {
+				"properties": Schema{"data": s.of(reflect.TypeOf(op.Data))},
+			}}}
+		}
+		success["content"] = Schema{"application/json": Schema{"schema": body}}
+	}
+	return Schema{
+		fmt.Sprint(status): success,
+		"default": Schema{
+			"description": "Error",
+			"content":     Schema{"application/problem+json": Schema{"schema": problem}},
+		},
+	}
+}
+
+// pathParams turns the httprouter pattern of op into an OpenAPI path and
+// documents its parameters. Those op does not describe are strings, and
+// UUIDs when their name ends in _id.
+func pathParams(s *schemas, op Operation) (string, []Schema) {
+	segments := strings.Split(op.Path, "/")
+	var params []Schema
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok {
+			continue
+		}
+		segments[i] = "{" + name + "}"
+		param := Param{Name: name}
+		if strings.HasSuffix(name, "_id") {
+			param.Value = uuid.UUID{}
+		}
+		for _, p := range op.PathParams {
+			if p.Name == name {
+				param = p
+			}
+		}
+		params = append(params, parameter(s, "path", param))
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func parameter(s *schemas, in string, p Param) Schema {
+	schema := Schema{"type": "string"}
+	if p.Value != nil {
+		schema = s.of(reflect.TypeOf(p.Value))
+	}
+	param := Schema{"name": p.Name, "in": in, "schema": schema}
+	if in == "path" || p.Required {
+		param["required"] = true
+	}
+	if p.Description != "" {
+		param["description"] = p.Description
+	}
+	return param
+}
+
+// operationID names op after its method and path, e.g.
+// patchApiUUsernameItemsItemId.
+func operationID(op Operation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, word := range strings.FieldsFunc(op.Path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '_' || r == '.' || r == '-'
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// Check reports routes that the operations do not document, and operations
+// for which no route exists. Each route is a method and an httprouter
+// pattern, such as "GET /api/u/:username".
+func Check(routes []string, ops []Operation) error {
+	documented := make(map[string]bool, len(ops))
+	for _, op := range ops {
+		documented[op.Method+" "+op.Path] = true
+	}
+	var errs []error
+	routed := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		routed[route] = true
+		if !documented[route] {
+			errs = append(errs, fmt.Errorf("route %s has no OpenAPI operation", route))
+		}
+	}
+	var stale []string
+	for route := range documented {
+		if !routed[route] {
+			stale = append(stale, route)
+		}
+	}
+	sort.Strings(stale)
+	for _, route := range stale {
+		errs = append(errs, fmt.Errorf("OpenAPI operation %s has no route", route))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	ops := []Operation{
+		{Method: http.MethodGet, Path: "/a"},
+		{Method: http.MethodPost, Path: "/b"},
+	}
+	if err := Check([]string{"GET /a", "POST /b"}, ops); err != nil {
+		t.Fatalf("matching routes: %v", err)
+	}
+
+	err := Check([]string{"GET /a", "DELETE /c"}, ops)
+	if err == nil {
+		t.Fatal("mismatched routes passed")
+	}
+	for _, want := range []string{"route DELETE /c has no OpenAPI operation", "OpenAPI operation POST /b has no route"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not report %q", err, want)
+		}
+	}
+}
+
+func TestPathParams(t *testing.T) {
+	path, params := pathParams(newSchemas(), Operation{Path: "/api/u/:username/items/:item_id"})
+	if path != "/api/u/{username}/items/{item_id}" {
+		t.Errorf("path = %s", path)
+	}
+	if len(params) != 2 {
+		t.Fatalf("got %d parameters, want 2", len(params))
+	}
+	if format := params[1]["schema"].(Schema)["format"]; format != "uuid" {
+		t.Errorf("item_id format = %v, want uuid", format)
+	}
+}
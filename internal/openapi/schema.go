@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a JSON Schema object as used by OpenAPI 3.1.
+type Schema map[string]any
+
+// optionalField is a merge patch field such as model.Optional. Its zero
+// value's FieldValue is a nil pointer to the type it holds.
+type optionalField interface {
+	FieldValue() any
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	uuidType       = reflect.TypeFor[uuid.UUID]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	optionalType   = reflect.TypeFor[optionalField]()
+)
+
+// schemas turns Go types into schemas, collecting each struct once under
+// components/schemas and referring to it by name.
+type schemas struct {
+	components map[string]Schema
+	types      map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]Schema{}, types: map[string]reflect.Type{}}
+}
+
+func (s *schemas) of(t reflect.Type) Schema {
+	if t.Implements(optionalType) {
+		value := reflect.Zero(t).Interface().(optionalField).FieldValue()
+		return nullable(s.of(reflect.TypeOf(value).Elem()))
+	}
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case uuidType:
+		return Schema{"type": "string", "format": "uuid"}
+	case rawMessageType:
+		return Schema{}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.of(t.Elem()))
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		return s.ref(t)
+	}
+	// Interfaces, such as the data of an envelope, may hold anything.
+	return Schema{}
+}
+
+// ref documents struct t once and returns a reference to it.
+func (s *schemas) ref(t reflect.Type) Schema {
+	name := t.Name()
+	if other, ok := s.types[name]; ok && other != t {
+		// Two packages use the name; qualify the later one.
+		name = path.Base(t.PkgPath()) + name
+	}
+	ref := Schema{"$ref": "#/components/schemas/" + name}
+	if _, ok := s.components[name]; ok {
+		return ref
+	}
+	// Claim the name first, so a struct that refers to itself terminates.
+	s.components[name] = Schema{}
+	s.types[name] = t
+
+	properties := Schema{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || key == "-" {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		schema := s.of(field.Type)
+		rules := strings.Split(field.Tag.Get("validate"), ",")
+		if rules[0] == "required" {
+			required = append(required, key)
+		}
+		applyRules(schema, field.Type, rules)
+		properties[key] = schema
+	}
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	s.components[name] = schema
+	return ref
+}
+
+// applyRules mirrors the validator rules of a field in its schema. Rules
+// after dive apply to the items of a list.
+func applyRules(schema Schema, t reflect.Type, rules []string) {
+	target, kind := schema, valueKind(t)
+	for _, rule := range rules {
+		tag, param, _ := strings.Cut(rule, "=")
+		switch tag {
+		case "dive":
+			if items, ok := target["items"].(Schema); ok {
+				target, kind = items, valueKind(t.Elem())
+			}
+		case "email":
+			target["format"] = "email"
+		case "url":
+			target["format"] = "uri"
+		case "oneof":
+			target["enum"] = strings.Fields(param)
+		case "min", "max", "gt", "gte":
+			if keyword, value, ok := bound(tag, param, kind); ok {
+				target[keyword] = value
+			}
+		}
+	}
+}
+
+// bound turns a min, max, gt or gte rule into a keyword, whose meaning
+// depends on the kind of value the rule checks.
+func bound(tag, param string, kind reflect.Kind) (string, json.Number, bool) {
+	var prefix string
+	switch kind {
+	case reflect.String:
+		prefix = "Length"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		prefix = "Items"
+	default:
+		switch tag {
+		case "min", "gte":
+			return "minimum", json.Number(param), true
+		case "max":
+			return "maximum", json.Number(param), true
+		}
+		return "exclusiveMinimum", json.Number(param), true
+	}
+	// Lengths are whole numbers, so "more than n" is "at least n+1".
+	n, err := strconv.Atoi(param)
+	if err != nil {
+		return "", "", false
+	}
+	switch tag {
+	case "max":
+		return "max" + prefix, json.Number(strconv.Itoa(n)), true
+	case "gt":
+		n++
+	}
+	return "min" + prefix, json.Number(strconv.Itoa(n)), true
+}
+
+// valueKind is the kind of value the validator sees for a field of type t.
+func valueKind(t reflect.Type) reflect.Kind {
+	if t.Implements(optionalType) {
+		t = reflect.TypeOf(reflect.Zero(t).Interface().(optionalField).FieldValue())
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind()
+}
+
+// nullable also admits null.
+func nullable(schema Schema) Schema {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+	return Schema{"anyOf": []Schema{schema, {"type": "null"}}}
+}
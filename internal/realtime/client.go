@@ -0,0 +1,85 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 512
+)
+
+// Client is one WebSocket connection. The connection is push only; anything
+// the client sends besides control frames is read and discarded.
+type Client struct {
+	hub  *Hub
+	conn *websocket.Conn
+	sub  *Subscription
+}
+
+// Serve subscribes conn to the events of userID and blocks until the
+// connection closes.
+func Serve(hub *Hub, conn *websocket.Conn, userID uuid.UUID) {
+	client := &Client{
+		hub:  hub,
+		conn: conn,
+		sub:  hub.Subscribe(userID),
+	}
+	go client.writePump()
+	client.readPump()
+}
+
+// readPump keeps the read deadline moving on every pong, so a peer that stops
+// answering pings is dropped after pongWait.
+func (c *Client) readPump() {
+	defer func() {
+		c.hub.Unsubscribe(c.sub)
+		c.conn.Close()
+	}()
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+	for {
+		select {
+		case event, ok := <-c.sub.Events():
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
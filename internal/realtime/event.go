@@ -0,0 +1,38 @@
+package realtime
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// Event types pushed to connected clients.
+const (
+	EventMessageCreated      = "message.created"
+	EventNotificationCreated = "notification.created"
+)
+
+// Event is what a client receives over its connection. ID is the event's
+// position in the user's event log. Truncated is set when the payload was
+// too large to fan out and the client should fetch the resource through the
+// REST API instead.
+type Event struct {
+	ID        int64           `json:"id,omitempty"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// envelope is an event addressed to one user, as sent through NOTIFY.
+type envelope struct {
+	UserID uuid.UUID `json:"user_id"`
+	Event  Event     `json:"event"`
+}
+
+func NewEvent(kind string, payload any) (Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: kind, Payload: raw}, nil
+}
@@ -0,0 +1,113 @@
+package realtime
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+)
+
+const sendBuffer = 64
+
+// Subscription receives the events of one user for one connection. The
+// channel is closed when the subscription is dropped.
+type Subscription struct {
+	userID    uuid.UUID
+	send      chan Event
+	closeOnce sync.Once
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.send
+}
+
+func (s *Subscription) close() {
+	s.closeOnce.Do(func() { close(s.send) })
+}
+
+// Hub tracks the open connections of every user on this instance. A user may
+// have several, one per tab or device, over WebSockets or SSE.
+type Hub struct {
+	mu     sync.RWMutex
+	subs   map[uuid.UUID]map[*Subscription]struct{}
+	closed bool
+	// listening is set while the listener holds its LISTEN connection;
+	// without it no event from another transaction reaches this instance.
+	listening atomic.Bool
+}
+
+// Listening reports whether events committed elsewhere are being received.
+func (h *Hub) Listening() bool {
+	return h.listening.Load()
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uuid.UUID]map[*Subscription]struct{})}
+}
+
+func (h *Hub) Subscribe(userID uuid.UUID) *Subscription {
+	sub := &Subscription{
+		userID: userID,
+		send:   make(chan Event, sendBuffer),
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		sub.close()
+		return sub
+	}
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := h.subs[sub.userID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.userID)
+	}
+	sub.close()
+}
+
+// Close drops every subscription, which ends their WebSocket and SSE
+// connections, and closes any subscribed afterwards straight away. It is
+// called on shutdown, since streams never finish on their own and would hold
+// up the drain.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for userID, subs := range h.subs {
+		for sub := range subs {
+			sub.close()
+		}
+		delete(h.subs, userID)
+	}
+}
+
+// Deliver queues event on every subscription of userID. A subscription whose
+// buffer is full is too slow to keep up; it is dropped rather than allowed to
+// stall delivery for everyone else, and can reconnect and catch up.
+func (h *Hub) Deliver(userID uuid.UUID, event Event) {
+	h.mu.RLock()
+	var slow []*Subscription
+	for sub := range h.subs[userID] {
+		select {
+		case sub.send <- event:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+	for _, sub := range slow {
+		h.Unsubscribe(sub)
+	}
+}
@@ -0,0 +1,50 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Listen holds one pooled connection on LISTEN and hands every event to the
+// hub, so users connected to any instance get events published by any other.
+// It reconnects until ctx is cancelled.
+func Listen(ctx context.Context, pool *pgxpool.Pool, hub *Hub) {
+	for ctx.Err() == nil {
+		if err := listen(ctx, pool, hub); err != nil && ctx.Err() == nil {
+			helper.ErrMsg(err, "realtime listener stopped, retrying: ")
+			select {
+			case <-ctx.Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+}
+
+func listen(ctx context.Context, pool *pgxpool.Pool, hub *Hub) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	hub.listening.Store(true)
+	defer hub.listening.Store(false)
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var env envelope
+		if err := json.Unmarshal([]byte(notification.Payload), &env); err != nil {
+			helper.ErrMsg(err, "invalid realtime event: ")
+			continue
+		}
+		hub.Deliver(env.UserID, env.Event)
+	}
+}
@@ -0,0 +1,83 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventLog reads back the events written by PGPublisher, so a client that
+// reconnects can receive what it missed.
+//
+// Event IDs come from one sequence and are handed out at insert time, so two
+// transactions for the same user can commit out of ID order. A client that
+// disconnects in that instant may skip the earlier event on resume; it is
+// still in the notification list.
+type EventLog struct {
+	db        *pgxpool.Pool
+	retention time.Duration
+}
+
+func NewEventLog(db *pgxpool.Pool, retention time.Duration) *EventLog {
+	return &EventLog{
+		db:        db,
+		retention: retention,
+	}
+}
+
+// Since returns up to limit events of userID logged after afterID, oldest
+// first.
+func (l *EventLog) Since(ctx context.Context, userID uuid.UUID, afterID int64, limit int) ([]Event, error) {
+	query := `
+		SELECT event_id, type, payload
+		FROM user_events
+		WHERE user_id = $1 AND event_id > $2
+		ORDER BY event_id
+		LIMIT $3
+	`
+	rows, err := l.db.Query(ctx, query, userID, afterID, limit)
+	if err != nil {
+		helper.ErrMsg(err, "failed to fetch events (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		if err := rows.Scan(&event.ID, &event.Type, &event.Payload); err != nil {
+			helper.ErrMsg(err, "scan events err: ")
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsg(rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return events, nil
+}
+
+// RunPruner drops events older than the retention period, once immediately
+// and then every hour until ctx is done.
+func (l *EventLog) RunPruner(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		tag, err := l.db.Exec(ctx, `DELETE FROM user_events WHERE created_at < $1`, time.Now().Add(-l.retention))
+		if err != nil {
+			helper.ErrMsg(err, "failed to prune events (db err): ")
+		} else if tag.RowsAffected() > 0 {
+			helper.SuccessMsg(fmt.Sprintf("pruned %d events", tag.RowsAffected()))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// notifyChannel is the Postgres channel every server instance listens on.
+const notifyChannel = "realtime_events"
+
+// maxNotifyPayload stays under Postgres' 8000 byte NOTIFY limit.
+const maxNotifyPayload = 7900
+
+type Publisher interface {
+	Publish(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event Event) error
+}
+
+// PGPublisher appends events to the user's event log and fans them out with
+// NOTIFY. Because both are transactional the event only reaches clients once
+// tx commits, and never if it rolls back.
+type PGPublisher struct{}
+
+func NewPublisher() Publisher {
+	return &PGPublisher{}
+}
+
+func (p *PGPublisher) Publish(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event Event) error {
+	query := `
+		INSERT INTO user_events (user_id, type, payload)
+		VALUES ($1, $2, $3)
+		RETURNING event_id
+	`
+	if err := tx.QueryRow(ctx, query, userID, event.Type, event.Payload).Scan(&event.ID); err != nil {
+		helper.ErrMsg(err, "failed to log event (db err): ")
+		return err
+	}
+	data, err := json.Marshal(envelope{UserID: userID, Event: event})
+	if err != nil {
+		return err
+	}
+	if len(data) > maxNotifyPayload {
+		event.Payload = nil
+		event.Truncated = true
+		if data, err = json.Marshal(envelope{UserID: userID, Event: event}); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(ctx, `SELECT pg_notify($1, $2)`, notifyChannel, string(data)); err != nil {
+		helper.ErrMsg(err, "failed to publish event (db err): ")
+		return err
+	}
+	return nil
+}
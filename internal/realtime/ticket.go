@@ -0,0 +1,90 @@
+package realtime
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TicketTTL is how long a stream ticket can be redeemed after it is issued.
+const TicketTTL = 30 * time.Second
+
+var ErrInvalidTicket = errors.New("stream ticket is invalid, expired or already used")
+
+// Ticket lets a browser open one WebSocket or SSE stream. Browsers cannot set
+// headers on either, and a ticket in the URL is far less harmful to leak
+// than the access token: it expires in seconds and works once.
+type Ticket struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Tickets issues and redeems stream tickets. They are kept in the database,
+// hashed, so any instance can redeem a ticket another one issued.
+type Tickets struct {
+	db *pgxpool.Pool
+}
+
+func NewTickets(db *pgxpool.Pool) *Tickets {
+	return &Tickets{
+		db: db,
+	}
+}
+
+// Issue creates a ticket for userID, clearing out expired ones on the way.
+func (t *Tickets) Issue(ctx context.Context, userID uuid.UUID) (*Ticket, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ticket := &Ticket{
+		Ticket:    hex.EncodeToString(secret),
+		ExpiresAt: now.Add(TicketTTL),
+	}
+	if _, err := t.db.Exec(ctx, `DELETE FROM stream_tickets WHERE expires_at < $1`, now); err != nil {
+		helper.ErrMsg(err, "failed to prune stream tickets (db err): ")
+		return nil, err
+	}
+	query := `
+		INSERT INTO stream_tickets (ticket_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	if _, err := t.db.Exec(ctx, query, ticketHash(ticket.Ticket), userID, ticket.ExpiresAt); err != nil {
+		helper.ErrMsg(err, "failed to issue stream ticket (db err): ")
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// Redeem uses up ticket and returns the user it was issued to.
+func (t *Tickets) Redeem(ctx context.Context, ticket string) (uuid.UUID, error) {
+	query := `
+		DELETE FROM stream_tickets
+		WHERE ticket_hash = $1 AND expires_at >= $2
+		RETURNING user_id
+	`
+	var userID uuid.UUID
+	err := t.db.QueryRow(ctx, query, ticketHash(ticket), time.Now()).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, ErrInvalidTicket
+		}
+		helper.ErrMsg(err, "failed to redeem stream ticket (db err): ")
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+func ticketHash(ticket string) []byte {
+	sum := sha256.Sum256([]byte(ticket))
+	return sum[:]
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type CommentRepoImpl interface {
+	CreateCommentRepo(ctx context.Context, tx pgx.Tx, comment *model.Comment) error
+	GetCommentByIDRepo(ctx context.Context, tx pgx.Tx, input *model.GetCommentInput) (*model.Comment, error)
+	GetCommentsRepo(ctx context.Context, tx pgx.Tx, page *model.CommentsPageReq) (*model.CommentsPageRes, error)
+	UpdateCommentRepo(ctx context.Context, tx pgx.Tx, comment *model.Comment) (*model.Comment, error)
+	DeleteCommentRepo(ctx context.Context, tx pgx.Tx, input *model.GetCommentInput) error
+	AdjustReplyCountRepo(ctx context.Context, tx pgx.Tx, commentID uuid.UUID, delta int) error
+}
+type CommentRepo struct{}
+
+func NewCommentRepository() CommentRepoImpl {
+	return &CommentRepo{}
+}
+
+// commentFields returns the scan targets for the comment columns every query
+// selects, in order: comment_id, post_id, parent_id, depth, content, owner,
+// user_id, reply_count, created_at, updated_at, deleted_at.
+func commentFields(comment *model.Comment) []any {
+	return []any{
+		&comment.CommentID,
+		&comment.PostID,
+		&comment.ParentID,
+		&comment.Depth,
+		&comment.Content,
+		&comment.Owner,
+		&comment.UserID,
+		&comment.ReplyCount,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.DeletedAt,
+	}
+}
+func (r *CommentRepo) CreateCommentRepo(ctx context.Context, tx pgx.Tx, comment *model.Comment) error {
+	query := `
+		INSERT INTO comments (comment_id, post_id, parent_id, depth, content, owner, user_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := tx.Exec(ctx, query,
+		comment.CommentID,
+		comment.PostID,
+		comment.ParentID,
+		comment.Depth,
+		comment.Content,
+		comment.Owner,
+		comment.UserID,
+		comment.CreatedAt,
+		comment.UpdatedAt,
+	)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create comment (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *CommentRepo) GetCommentByIDRepo(ctx context.Context, tx pgx.Tx, input *model.GetCommentInput) (*model.Comment, error) {
+	query := `
+		SELECT comment_id, post_id, parent_id, depth, content, owner, user_id, reply_count, created_at, updated_at, deleted_at
+		FROM comments
+		WHERE comment_id = $1 AND post_id = $2 AND deleted_at IS NULL
+	`
+	var comment model.Comment
+	err := tx.QueryRow(ctx, query, input.CommentID, input.PostID).Scan(commentFields(&comment)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("comment not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to fetch comment (db err): ")
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetCommentsRepo lists the direct children of page.ParentID, or the
+// top-level comments when it is nil, oldest first. Deleted comments are
+// kept as placeholders while they still have replies.
+func (r *CommentRepo) GetCommentsRepo(ctx context.Context, tx pgx.Tx, page *model.CommentsPageReq) (*model.CommentsPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM comments
+		WHERE post_id = $1 AND parent_id IS NOT DISTINCT FROM $2
+			AND (deleted_at IS NULL OR reply_count > 0)
+	`
+	var totalComments int
+	err := tx.QueryRow(ctx, count, page.PostID, page.ParentID).Scan(&totalComments)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count comments (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT comment_id, post_id, parent_id, depth, content, owner, user_id, reply_count, created_at, updated_at, deleted_at
+		FROM comments
+		WHERE post_id = $1 AND parent_id IS NOT DISTINCT FROM $2
+			AND (deleted_at IS NULL OR reply_count > 0)
+		ORDER BY created_at ASC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := tx.Query(ctx, query, page.PostID, page.ParentID, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch comments (db err): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.CommentsPageRes
+	res.Comments = []model.Comment{}
+	for rows.Next() {
+		var comment model.Comment
+		if err := rows.Scan(commentFields(&comment)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan comments err: ")
+			return nil, err
+		}
+		res.Comments = append(res.Comments, comment)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalComments = totalComments
+	res.TotalPages = int(math.Ceil(float64(res.TotalComments) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Comments)
+	return &res, nil
+}
+func (r *CommentRepo) UpdateCommentRepo(ctx context.Context, tx pgx.Tx, comment *model.Comment) (*model.Comment, error) {
+	query := `
+		UPDATE comments
+		SET content = $1,
+			updated_at = $2
+		WHERE comment_id = $3 AND deleted_at IS NULL
+		RETURNING comment_id, post_id, parent_id, depth, content, owner, user_id, reply_count, created_at, updated_at, deleted_at
+	`
+	var updated model.Comment
+	err := tx.QueryRow(ctx, query, comment.Content, comment.UpdatedAt, comment.CommentID).Scan(commentFields(&updated)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("comment not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to update comment (db err): ")
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteCommentRepo blanks the comment instead of removing the row, so that
+// replies underneath it keep their place in the thread.
+func (r *CommentRepo) DeleteCommentRepo(ctx context.Context, tx pgx.Tx, input *model.GetCommentInput) error {
+	query := `
+		UPDATE comments
+		SET content = '',
+			deleted_at = $1
+		WHERE comment_id = $2 AND post_id = $3 AND deleted_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, query, time.Now(), input.CommentID, input.PostID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete comment (db err): ")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.NotFound("comment not found")
+	}
+	return nil
+}
+func (r *CommentRepo) AdjustReplyCountRepo(ctx context.Context, tx pgx.Tx, commentID uuid.UUID, delta int) error {
+	query := `
+		UPDATE comments
+		SET reply_count = GREATEST(reply_count + $1, 0)
+		WHERE comment_id = $2
+	`
+	_, err := tx.Exec(ctx, query, delta, commentID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update reply count (db err): ")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"math"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type FollowRepoImpl interface {
+	GetUserIDRepo(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error)
+	FollowRepo(ctx context.Context, tx pgx.Tx, follow *model.Follow) (bool, error)
+	UnfollowRepo(ctx context.Context, tx pgx.Tx, followerID, followeeID uuid.UUID) (bool, error)
+	AdjustFollowCountsRepo(ctx context.Context, tx pgx.Tx, followerID, followeeID uuid.UUID, delta int) error
+	GetFollowersRepo(ctx context.Context, tx pgx.Tx, page *model.FollowsPageReq) (*model.FollowsPageRes, error)
+	GetFollowingRepo(ctx context.Context, tx pgx.Tx, page *model.FollowsPageReq) (*model.FollowsPageRes, error)
+}
+type FollowRepo struct{}
+
+func NewFollowRepository() FollowRepoImpl {
+	return &FollowRepo{}
+}
+
+func (r *FollowRepo) GetUserIDRepo(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error) {
+	return userIDByUsername(ctx, tx, username)
+}
+
+// userIDByUsername is shared by repositories that address users by the
+// username in the URL.
+func userIDByUsername(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error) {
+	query := `
+		SELECT user_id
+		FROM users
+		WHERE username = $1
+	`
+	var userID uuid.UUID
+	err := tx.QueryRow(ctx, query, username).Scan(&userID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, model.NotFound("user not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to find user (db err): ")
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// FollowRepo reports whether a new follow was created, so callers only bump
+// the counters when something actually changed.
+func (r *FollowRepo) FollowRepo(ctx context.Context, tx pgx.Tx, follow *model.Follow) (bool, error) {
+	query := `
+		INSERT INTO follows (follower_id, followee_id, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING
+	`
+	tag, err := tx.Exec(ctx, query, follow.FollowerID, follow.FolloweeID, follow.CreatedAt)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to follow user (db err): ")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+func (r *FollowRepo) UnfollowRepo(ctx context.Context, tx pgx.Tx, followerID, followeeID uuid.UUID) (bool, error) {
+	query := `
+		DELETE FROM follows
+		WHERE follower_id = $1 AND followee_id = $2
+	`
+	tag, err := tx.Exec(ctx, query, followerID, followeeID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to unfollow user (db err): ")
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+func (r *FollowRepo) AdjustFollowCountsRepo(ctx context.Context, tx pgx.Tx, followerID, followeeID uuid.UUID, delta int) error {
+	query := `
+		UPDATE users
+		SET following_count = GREATEST(following_count + CASE WHEN user_id = $2 THEN $1 ELSE 0 END, 0),
+			follower_count = GREATEST(follower_count + CASE WHEN user_id = $3 THEN $1 ELSE 0 END, 0)
+		WHERE user_id IN ($2, $3)
+	`
+	_, err := tx.Exec(ctx, query, delta, followerID, followeeID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update follow counts (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *FollowRepo) GetFollowersRepo(ctx context.Context, tx pgx.Tx, page *model.FollowsPageReq) (*model.FollowsPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM follows f
+		JOIN users u ON u.user_id = f.followee_id
+		WHERE u.username = $1
+	`
+	query := `
+		SELECT fu.user_id, fu.username, f.created_at
+		FROM follows f
+		JOIN users u ON u.user_id = f.followee_id
+		JOIN users fu ON fu.user_id = f.follower_id
+		WHERE u.username = $1
+		ORDER BY f.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.getFollowsPage(ctx, tx, count, query, page)
+}
+func (r *FollowRepo) GetFollowingRepo(ctx context.Context, tx pgx.Tx, page *model.FollowsPageReq) (*model.FollowsPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM follows f
+		JOIN users u ON u.user_id = f.follower_id
+		WHERE u.username = $1
+	`
+	query := `
+		SELECT fu.user_id, fu.username, f.created_at
+		FROM follows f
+		JOIN users u ON u.user_id = f.follower_id
+		JOIN users fu ON fu.user_id = f.followee_id
+		WHERE u.username = $1
+		ORDER BY f.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	return r.getFollowsPage(ctx, tx, count, query, page)
+}
+func (r *FollowRepo) getFollowsPage(ctx context.Context, tx pgx.Tx, count, query string, page *model.FollowsPageReq) (*model.FollowsPageRes, error) {
+	var totalUsers int
+	err := tx.QueryRow(ctx, count, page.Username).Scan(&totalUsers)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count follows (db err)")
+		return nil, err
+	}
+	rows, err := tx.Query(ctx, query, page.Username, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch follows (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.FollowsPageRes
+	res.Users = []model.FollowUser{}
+	for rows.Next() {
+		var user model.FollowUser
+		if err := rows.Scan(&user.UserID, &user.Username, &user.FollowedAt); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan follows err: ")
+			return nil, err
+		}
+		res.Users = append(res.Users, user)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalUsers = totalUsers
+	res.TotalPages = int(math.Ceil(float64(res.TotalUsers) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Users)
+	return &res, nil
+}
@@ -2,8 +2,8 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"math"
+	"time"
 
 	"github.com/bagasadiii/buy-n-con/helper"
 	"github.com/bagasadiii/buy-n-con/internal/model"
@@ -11,94 +11,120 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-type ItemRepoImpl interface{
-	CreateItemRepo(ctx context.Context, tx pgx.Tx, item *model.Item)error
-	GetItemByIDRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput)(*model.ItemResp, error)
-	GetAllItemsRepo(ctx context.Context, tx pgx.Tx, page *model.ItemsPageReq)(*model.ItemsPageRes, error)
-	ItemUpdateRepo(ctx context.Context, tx pgx.Tx, input *model.UpdateItemInput, id uuid.UUID)(*model.ItemResp, error)
-	ItemDeleteRepo(ctx context.Context, tx pgx.Tx, id *uuid.UUID)error
+type ItemRepoImpl interface {
+	CreateItemRepo(ctx context.Context, tx pgx.Tx, item *model.Item) error
+	GetItemByIDRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput) (*model.ItemResp, error)
+	GetAllItemsRepo(ctx context.Context, tx pgx.Tx, page *model.ItemsPageReq) (*model.ItemsPageRes, error)
+	ItemUpdateRepo(ctx context.Context, tx pgx.Tx, item *model.ItemResp, version int) (*model.ItemResp, error)
+	ItemDeleteRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput, version int) error
+	GetDeletedItemsRepo(ctx context.Context, tx pgx.Tx, page *model.ItemsPageReq) (*model.ItemsPageRes, error)
+	RestoreItemRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput) (*model.ItemResp, error)
+	PurgeDeletedItemsRepo(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error)
+	UpdateItemStatusRepo(ctx context.Context, tx pgx.Tx, status model.ItemStatus, id uuid.UUID, version int) (*model.ItemResp, error)
+	CreateItemRevisionsRepo(ctx context.Context, tx pgx.Tx, revisions []model.ItemRevision) error
+	GetItemHistoryRepo(ctx context.Context, tx pgx.Tx, page *model.ItemHistoryReq) (*model.ItemHistoryRes, error)
+	GetFeedItemsRepo(ctx context.Context, tx pgx.Tx, req *model.FeedReq) ([]model.ItemResp, error)
+	GetItemWatchersRepo(ctx context.Context, tx pgx.Tx, itemID uuid.UUID) ([]uuid.UUID, error)
+	GetUserIDRepo(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error)
 }
 type ItemRepo struct{}
 
+// itemFields returns the scan targets for the item columns every query
+// selects, in order: item_id, owner, name, quantity, price, previous_price,
+// description, status, created_at, updated_at, version, reaction_counts.
+// Extra targets are appended.
+func itemFields(item *model.ItemResp, extra ...any) []any {
+	fields := []any{
+		&item.ItemID,
+		&item.Owner,
+		&item.Name,
+		&item.Quantity,
+		&item.Price,
+		&item.PriceDroppedFrom,
+		&item.Description,
+		&item.Status,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&item.Version,
+		&item.ReactionCounts,
+	}
+	return append(fields, extra...)
+}
+
 func NewItemRepository() ItemRepoImpl {
 	return &ItemRepo{}
 }
 
-func(r *ItemRepo)CreateItemRepo(ctx context.Context, tx pgx.Tx, item *model.Item)error{
+func (r *ItemRepo) CreateItemRepo(ctx context.Context, tx pgx.Tx, item *model.Item) error {
 	query := `
-		INSERT INTO items (item_id, user_id, owner, name, quantity, price, description, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		INSERT INTO items (item_id, user_id, owner, name, quantity, price, description, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`
-	_, err := tx.Exec(ctx, query, 
-		item.ItemID, 
-		item.UserID, 
+	_, err := tx.Exec(ctx, query,
+		item.ItemID,
+		item.UserID,
 		item.Owner,
 		item.Name,
 		item.Quantity,
 		item.Price,
 		item.Description,
+		item.Status,
 		item.CreatedAt,
 		item.UpdatedAt,
 	)
 	if err != nil {
-		helper.ErrMsg(err, "failed to create item: ")
+		helper.ErrMsgCtx(ctx, err, "failed to create item: ")
 		return err
 	}
-	helper.SuccessMsg("item created")
+	helper.SuccessMsgCtx(ctx, "item created")
 	return nil
 }
-func(r *ItemRepo)GetItemByIDRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput)(*model.ItemResp, error){
+func (r *ItemRepo) GetItemByIDRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput) (*model.ItemResp, error) {
 	query := `
-		SELECT item_id, owner, name, quantity, price, description, created_at, updated_at
+		SELECT item_id, owner, name, quantity, price, previous_price, description, status, created_at, updated_at, version, reaction_counts,
+			(SELECT reaction FROM item_reactions ir WHERE ir.item_id = items.item_id AND ir.user_id = $4)
 		FROM items
-		WHERE item_id = $1 AND owner = $2
+		WHERE item_id = $1 AND owner = $2 AND deleted_at IS NULL
+			AND (status IN ('active', 'reserved', 'sold') OR owner = $3)
 	`
 	var item model.ItemResp
-	row := tx.QueryRow(ctx, query, input.ItemID, input.Owner)
-	err := row.Scan(
-		&item.ItemID,
-		&item.Owner,
-		&item.Name, 
-		&item.Quantity,
-		&item.Price,
-		&item.Description,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	)
+	row := tx.QueryRow(ctx, query, input.ItemID, input.Owner, input.Viewer, input.ViewerID)
+	err := row.Scan(itemFields(&item, &item.MyReaction)...)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("item not found")
 		}
-		helper.ErrMsg(err, "failed to fetch item (db error): ")
+		helper.ErrMsgCtx(ctx, err, "failed to fetch item (db error): ")
 		return nil, err
 	}
 	return &item, nil
 }
-func(r *ItemRepo)GetAllItemsRepo(ctx context.Context, tx pgx.Tx, page *model.ItemsPageReq)(*model.ItemsPageRes, error){
+func (r *ItemRepo) GetAllItemsRepo(ctx context.Context, tx pgx.Tx, page *model.ItemsPageReq) (*model.ItemsPageRes, error) {
 	count := `
 		SELECT COUNT (*)
 		FROM items
-		WHERE owner = $1
+		WHERE owner = $1 AND status = $2 AND deleted_at IS NULL
 	`
-	var totalItems int 
-	err := tx.QueryRow(ctx, count, page.Username).Scan(&totalItems)
+	var totalItems int
+	err := tx.QueryRow(ctx, count, page.Username, page.Status).Scan(&totalItems)
 	if err != nil {
-		helper.ErrMsg(err, "failed to count(db err)")
+		helper.ErrMsgCtx(ctx, err, "failed to count(db err)")
 		return nil, err
 	}
 	query := `
-		SELECT item_id, owner, name, quantity, price, description, created_at, updated_at
+		SELECT item_id, owner, name, quantity, price, previous_price, description, status, created_at, updated_at, version, reaction_counts,
+			(SELECT reaction FROM item_reactions ir WHERE ir.item_id = items.item_id AND ir.user_id = $5)
 		FROM items
-		WHERE owner = $1
+		WHERE owner = $1 AND status = $2 AND deleted_at IS NULL
 		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		LIMIT $3 OFFSET $4
 	`
-	rows, err := tx.Query(ctx, query, page.Username, page.Limit, page.Offset)
+	rows, err := tx.Query(ctx, query, page.Username, page.Status, page.Limit, page.Offset, page.ViewerID)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("item not found")
 		}
-		helper.ErrMsg(err, "failed to fetch items (db err): ")
+		helper.ErrMsgCtx(ctx, err, "failed to fetch items (db err): ")
 		return nil, err
 	}
 	defer rows.Close()
@@ -108,84 +134,348 @@ func(r *ItemRepo)GetAllItemsRepo(ctx context.Context, tx pgx.Tx, page *model.Ite
 
 	for rows.Next() {
 		var item model.ItemResp
-		err := rows.Scan(
-			&item.ItemID,
-			&item.Owner,
-			&item.Name, 
-			&item.Quantity,
-			&item.Price,
-			&item.Description,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-		)
+		err := rows.Scan(itemFields(&item, &item.MyReaction)...)
 		if err != nil {
-			helper.ErrMsg(err, "scan items err: ")
+			helper.ErrMsgCtx(ctx, err, "scan items err: ")
 			return nil, err
 		}
 		res.Items = append(res.Items, item)
 	}
 	if rows.Err() != nil {
-		helper.ErrMsg(rows.Err(), "iteration rows err: ")
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
 		return nil, rows.Err()
 	}
 	res.TotalItems = totalItems
 	res.TotalPages = int(math.Ceil(float64(res.TotalItems) / float64(page.Limit)))
-	res.Current = (page.Offset/page.Limit) + 1
+	res.Current = (page.Offset / page.Limit) + 1
 	res.PageSize = len(res.Items)
 	return &res, nil
 }
-func(r *ItemRepo)ItemUpdateRepo(ctx context.Context, tx pgx.Tx, input *model.UpdateItemInput, id uuid.UUID)(*model.ItemResp, error){
+
+// ItemUpdateRepo writes the merged item only when the row is still at the
+// given version, so ErrNoRows here means someone else changed it after it
+// was read.
+func (r *ItemRepo) ItemUpdateRepo(ctx context.Context, tx pgx.Tx, item *model.ItemResp, version int) (*model.ItemResp, error) {
 	query := `
 		UPDATE items
-		SET name = COALESCE($1, name),
-			quantity = COALESCE($2, quantity),
-			price = COALESCE($3, price), 
-			description = COALESCE($4, description),
-			updated_at = $5
-		WHERE item_id = $6
-		RETURNING item_id, owner, name, quantity, price, description, created_at, updated_at
+		SET name = $1,
+			quantity = $2,
+			previous_price = CASE
+				WHEN $3 < price THEN price
+				WHEN $3 > price THEN NULL
+				ELSE previous_price
+			END,
+			price = $3,
+			description = $4,
+			status = $5,
+			updated_at = $6,
+			version = version + 1
+		WHERE item_id = $7 AND version = $8 AND deleted_at IS NULL
+		RETURNING item_id, owner, name, quantity, price, previous_price, description, status, created_at, updated_at, version, reaction_counts
 	`
 	var updatedItem model.ItemResp
 	err := tx.QueryRow(ctx, query,
-		input.Name,
-		input.Quantity,
-		input.Price,
-		input.Description,
-		input.UpdatedAt,
-		id,
-	).Scan(
-		&updatedItem.ItemID,
-		&updatedItem.Owner,
-		&updatedItem.Name, 
-		&updatedItem.Quantity,
-		&updatedItem.Price,
-		&updatedItem.Description,
-		&updatedItem.CreatedAt,
-		&updatedItem.UpdatedAt,
-	)
+		item.Name,
+		item.Quantity,
+		item.Price,
+		item.Description,
+		item.Status,
+		item.UpdatedAt,
+		item.ItemID,
+		version,
+	).Scan(itemFields(&updatedItem)...)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.ErrVersionConflict
 		}
-		helper.ErrMsg(err, "failed to update item (db err): ")
+		helper.ErrMsgCtx(ctx, err, "failed to update item (db err): ")
 		return nil, err
 	}
 	return &updatedItem, nil
 }
-func(r *ItemRepo)ItemDeleteRepo(ctx context.Context, tx pgx.Tx, id *uuid.UUID) error {
-    query := `
-        DELETE FROM items
-        WHERE item_id = $1
-    `
-
-    _, err := tx.Exec(ctx, query, id)
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return errors.New("item not found")
-        }
-        helper.ErrMsg(err, "failed to delete item (db err): ")
-        return err
-    }
-
-    return nil
+func (r *ItemRepo) ItemDeleteRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput, version int) error {
+	query := `
+		UPDATE items
+		SET deleted_at = $1,
+			version = version + 1
+		WHERE item_id = $2 AND owner = $3 AND version = $4 AND deleted_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, query, time.Now(), input.ItemID, input.Owner, version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete item (db err): ")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrVersionConflict
+	}
+	return nil
+}
+func (r *ItemRepo) GetDeletedItemsRepo(ctx context.Context, tx pgx.Tx, page *model.ItemsPageReq) (*model.ItemsPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM items
+		WHERE owner = $1 AND deleted_at IS NOT NULL
+	`
+	var totalItems int
+	err := tx.QueryRow(ctx, count, page.Username).Scan(&totalItems)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count deleted items (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT item_id, owner, name, quantity, price, previous_price, description, status, created_at, updated_at, version, reaction_counts, deleted_at
+		FROM items
+		WHERE owner = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := tx.Query(ctx, query, page.Username, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch deleted items (db err): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.ItemsPageRes
+	res.Items = []model.ItemResp{}
+
+	for rows.Next() {
+		var item model.ItemResp
+		err := rows.Scan(itemFields(&item, &item.DeletedAt)...)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan deleted items err: ")
+			return nil, err
+		}
+		res.Items = append(res.Items, item)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalItems = totalItems
+	res.TotalPages = int(math.Ceil(float64(res.TotalItems) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Items)
+	return &res, nil
+}
+func (r *ItemRepo) RestoreItemRepo(ctx context.Context, tx pgx.Tx, input *model.GetItemInput) (*model.ItemResp, error) {
+	query := `
+		UPDATE items
+		SET deleted_at = NULL,
+			updated_at = $1,
+			version = version + 1
+		WHERE item_id = $2 AND owner = $3 AND deleted_at IS NOT NULL
+		RETURNING item_id, owner, name, quantity, price, previous_price, description, status, created_at, updated_at, version, reaction_counts
+	`
+	var item model.ItemResp
+	err := tx.QueryRow(ctx, query, time.Now(), input.ItemID, input.Owner).Scan(itemFields(&item)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("item not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to restore item (db err): ")
+		return nil, err
+	}
+	return &item, nil
+}
+func (r *ItemRepo) PurgeDeletedItemsRepo(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error) {
+	query := `
+		DELETE FROM items
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	`
+	tag, err := tx.Exec(ctx, query, before)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to purge deleted items (db err): ")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+func (r *ItemRepo) UpdateItemStatusRepo(ctx context.Context, tx pgx.Tx, status model.ItemStatus, id uuid.UUID, version int) (*model.ItemResp, error) {
+	query := `
+		UPDATE items
+		SET status = $1,
+			updated_at = $2,
+			version = version + 1
+		WHERE item_id = $3 AND version = $4 AND deleted_at IS NULL
+		RETURNING item_id, owner, name, quantity, price, previous_price, description, status, created_at, updated_at, version, reaction_counts
+	`
+	var item model.ItemResp
+	err := tx.QueryRow(ctx, query, status, time.Now(), id, version).Scan(itemFields(&item)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.ErrVersionConflict
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to update item status (db err): ")
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *ItemRepo) CreateItemRevisionsRepo(ctx context.Context, tx pgx.Tx, revisions []model.ItemRevision) error {
+	query := `
+		INSERT INTO item_revisions (revision_id, item_id, user_id, changed_by, field, old_value, new_value, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for _, rev := range revisions {
+		_, err := tx.Exec(ctx, query,
+			rev.RevisionID,
+			rev.ItemID,
+			rev.UserID,
+			rev.ChangedBy,
+			rev.Field,
+			rev.OldValue,
+			rev.NewValue,
+			rev.ChangedAt,
+		)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "failed to record item revision (db err): ")
+			return err
+		}
+	}
+	return nil
+}
+func (r *ItemRepo) GetItemHistoryRepo(ctx context.Context, tx pgx.Tx, page *model.ItemHistoryReq) (*model.ItemHistoryRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM item_revisions
+		WHERE item_id = $1
+	`
+	var total int
+	err := tx.QueryRow(ctx, count, page.ItemID).Scan(&total)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count item revisions (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT revision_id, item_id, user_id, changed_by, field, old_value, new_value, changed_at
+		FROM item_revisions
+		WHERE item_id = $1
+		ORDER BY changed_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := tx.Query(ctx, query, page.ItemID, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch item revisions (db err): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.ItemHistoryRes
+	res.Revisions = []model.ItemRevision{}
+	for rows.Next() {
+		var rev model.ItemRevision
+		err := rows.Scan(
+			&rev.RevisionID,
+			&rev.ItemID,
+			&rev.UserID,
+			&rev.ChangedBy,
+			&rev.Field,
+			&rev.OldValue,
+			&rev.NewValue,
+			&rev.ChangedAt,
+		)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan item revisions err: ")
+			return nil, err
+		}
+		res.Revisions = append(res.Revisions, rev)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalRevisions = total
+	res.TotalPages = int(math.Ceil(float64(total) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Revisions)
+	return &res, nil
+}
+
+// GetFeedItemsRepo is the item side of the home feed: public listings of
+// followed users, newest first, paged the same way as GetFeedPostsRepo.
+func (r *ItemRepo) GetFeedItemsRepo(ctx context.Context, tx pgx.Tx, req *model.FeedReq) ([]model.ItemResp, error) {
+	query := `
+		SELECT i.item_id, i.owner, i.name, i.quantity, i.price, i.previous_price, i.description, i.status, i.created_at, i.updated_at, i.version, i.reaction_counts,
+			(SELECT reaction FROM item_reactions ir WHERE ir.item_id = i.item_id AND ir.user_id = $1)
+		FROM follows f
+		CROSS JOIN LATERAL (
+			SELECT *
+			FROM items
+			WHERE items.user_id = f.followee_id AND items.deleted_at IS NULL
+				AND items.status IN ('active', 'reserved', 'sold')
+				AND ($2::timestamptz IS NULL OR (items.created_at, items.item_id) < ($2, $3::uuid))
+			ORDER BY items.created_at DESC, items.item_id DESC
+			LIMIT $4
+		) i
+		WHERE f.follower_id = $1
+		ORDER BY i.created_at DESC, i.item_id DESC
+		LIMIT $4
+	`
+	var cursorAt *time.Time
+	var cursorID *uuid.UUID
+	if req.Cursor != nil {
+		cursorAt, cursorID = &req.Cursor.CreatedAt, &req.Cursor.ID
+	}
+	rows, err := tx.Query(ctx, query, req.UserID, cursorAt, cursorID, req.Limit)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch feed items (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []model.ItemResp{}
+	for rows.Next() {
+		var item model.ItemResp
+		if err := rows.Scan(itemFields(&item, &item.MyReaction)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan feed items err: ")
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return items, nil
+}
+
+// GetItemWatchersRepo returns the users who have shown interest in an item,
+// by reacting to it or asking the seller about it, other than the seller.
+func (r *ItemRepo) GetItemWatchersRepo(ctx context.Context, tx pgx.Tx, itemID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+		SELECT ir.user_id
+		FROM item_reactions ir
+		JOIN items i ON i.item_id = ir.item_id
+		WHERE ir.item_id = $1 AND ir.user_id IS DISTINCT FROM i.user_id
+		UNION
+		SELECT cm.user_id
+		FROM conversations c
+		JOIN conversation_members cm ON cm.conversation_id = c.conversation_id
+		JOIN items i ON i.item_id = c.item_id
+		WHERE c.item_id = $1 AND cm.user_id IS DISTINCT FROM i.user_id
+	`
+	rows, err := tx.Query(ctx, query, itemID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch item watchers (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var watchers []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan item watchers err: ")
+			return nil, err
+		}
+		watchers = append(watchers, userID)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return watchers, nil
+}
+func (r *ItemRepo) GetUserIDRepo(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error) {
+	return userIDByUsername(ctx, tx, username)
 }
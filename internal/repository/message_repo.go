@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type MessageRepoImpl interface {
+	GetUserIDRepo(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error)
+	GetItemOwnerIDRepo(ctx context.Context, tx pgx.Tx, itemID uuid.UUID) (uuid.UUID, error)
+	IsBlockedRepo(ctx context.Context, tx pgx.Tx, a, b uuid.UUID) (bool, error)
+	BlockRepo(ctx context.Context, tx pgx.Tx, blockerID, blockedID uuid.UUID) error
+	UnblockRepo(ctx context.Context, tx pgx.Tx, blockerID, blockedID uuid.UUID) error
+	GetOrCreateConversationRepo(ctx context.Context, tx pgx.Tx, userA, userB uuid.UUID, itemID *uuid.UUID) (uuid.UUID, error)
+	GetConversationRepo(ctx context.Context, tx pgx.Tx, conversationID, userID uuid.UUID) (*model.Conversation, error)
+	GetConversationsRepo(ctx context.Context, tx pgx.Tx, page *model.ConversationsPageReq) (*model.ConversationsPageRes, error)
+	CreateMessageRepo(ctx context.Context, tx pgx.Tx, message *model.Message) error
+	GetMessagesRepo(ctx context.Context, tx pgx.Tx, page *model.MessagesPageReq) (*model.MessagesPageRes, error)
+	MarkConversationReadRepo(ctx context.Context, tx pgx.Tx, conversationID, userID uuid.UUID, readAt time.Time) error
+}
+type MessageRepo struct{}
+
+func NewMessageRepository() MessageRepoImpl {
+	return &MessageRepo{}
+}
+
+// conversationSelect reads a conversation from the point of view of the
+// member bound to $1.
+const conversationSelect = `
+	SELECT c.conversation_id, c.item_id, peer.user_id, peer.username, me.unread_count, pm.last_read_at, c.last_message_at, c.created_at
+	FROM conversation_members me
+	JOIN conversations c ON c.conversation_id = me.conversation_id
+	JOIN conversation_members pm ON pm.conversation_id = c.conversation_id AND pm.user_id <> me.user_id
+	JOIN users peer ON peer.user_id = pm.user_id
+`
+
+func conversationFields(conversation *model.Conversation) []any {
+	return []any{
+		&conversation.ConversationID,
+		&conversation.ItemID,
+		&conversation.Peer.UserID,
+		&conversation.Peer.Username,
+		&conversation.UnreadCount,
+		&conversation.PeerReadAt,
+		&conversation.LastMessageAt,
+		&conversation.CreatedAt,
+	}
+}
+
+func (r *MessageRepo) GetUserIDRepo(ctx context.Context, tx pgx.Tx, username string) (uuid.UUID, error) {
+	return userIDByUsername(ctx, tx, username)
+}
+func (r *MessageRepo) GetItemOwnerIDRepo(ctx context.Context, tx pgx.Tx, itemID uuid.UUID) (uuid.UUID, error) {
+	query := `
+		SELECT user_id
+		FROM items
+		WHERE item_id = $1 AND deleted_at IS NULL
+	`
+	var ownerID uuid.UUID
+	err := tx.QueryRow(ctx, query, itemID).Scan(&ownerID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return uuid.Nil, model.NotFound("item not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to find item (db err): ")
+		return uuid.Nil, err
+	}
+	return ownerID, nil
+}
+
+// IsBlockedRepo reports whether either user has blocked the other.
+func (r *MessageRepo) IsBlockedRepo(ctx context.Context, tx pgx.Tx, a, b uuid.UUID) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)
+	`
+	var blocked bool
+	if err := tx.QueryRow(ctx, query, a, b).Scan(&blocked); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to check block (db err): ")
+		return false, err
+	}
+	return blocked, nil
+}
+func (r *MessageRepo) BlockRepo(ctx context.Context, tx pgx.Tx, blockerID, blockedID uuid.UUID) error {
+	query := `
+		INSERT INTO blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, blockerID, blockedID); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to block user (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *MessageRepo) UnblockRepo(ctx context.Context, tx pgx.Tx, blockerID, blockedID uuid.UUID) error {
+	query := `
+		DELETE FROM blocks
+		WHERE blocker_id = $1 AND blocked_id = $2
+	`
+	if _, err := tx.Exec(ctx, query, blockerID, blockedID); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to unblock user (db err): ")
+		return err
+	}
+	return nil
+}
+
+// GetOrCreateConversationRepo returns the conversation between userA and
+// userB about itemID, creating it on first contact. userA and userB must be
+// ordered with model.ConversationMembers.
+func (r *MessageRepo) GetOrCreateConversationRepo(ctx context.Context, tx pgx.Tx, userA, userB uuid.UUID, itemID *uuid.UUID) (uuid.UUID, error) {
+	query := `
+		INSERT INTO conversations (conversation_id, user_a, user_b, item_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_a, user_b, COALESCE(item_id, '00000000-0000-0000-0000-000000000000'::uuid)) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, uuid.New(), userA, userB, itemID); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create conversation (db err): ")
+		return uuid.Nil, err
+	}
+	query = `
+		SELECT conversation_id
+		FROM conversations
+		WHERE user_a = $1 AND user_b = $2 AND item_id IS NOT DISTINCT FROM $3
+	`
+	var conversationID uuid.UUID
+	if err := tx.QueryRow(ctx, query, userA, userB, itemID).Scan(&conversationID); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to find conversation (db err): ")
+		return uuid.Nil, err
+	}
+	query = `
+		INSERT INTO conversation_members (conversation_id, user_id)
+		VALUES ($1, $2), ($1, $3)
+		ON CONFLICT (conversation_id, user_id) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, conversationID, userA, userB); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to add conversation members (db err): ")
+		return uuid.Nil, err
+	}
+	return conversationID, nil
+}
+
+// GetConversationRepo only finds conversations userID is a member of.
+func (r *MessageRepo) GetConversationRepo(ctx context.Context, tx pgx.Tx, conversationID, userID uuid.UUID) (*model.Conversation, error) {
+	query := conversationSelect + `
+		WHERE me.user_id = $1 AND c.conversation_id = $2
+	`
+	var conversation model.Conversation
+	err := tx.QueryRow(ctx, query, userID, conversationID).Scan(conversationFields(&conversation)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("conversation not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to fetch conversation (db error): ")
+		return nil, err
+	}
+	return &conversation, nil
+}
+func (r *MessageRepo) GetConversationsRepo(ctx context.Context, tx pgx.Tx, page *model.ConversationsPageReq) (*model.ConversationsPageRes, error) {
+	count := `
+		SELECT COUNT (*), COALESCE(SUM(unread_count), 0)
+		FROM conversation_members
+		WHERE user_id = $1
+	`
+	var res model.ConversationsPageRes
+	err := tx.QueryRow(ctx, count, page.UserID).Scan(&res.TotalConversations, &res.TotalUnread)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count conversations (db err)")
+		return nil, err
+	}
+	query := conversationSelect + `
+		WHERE me.user_id = $1
+		ORDER BY COALESCE(c.last_message_at, c.created_at) DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := tx.Query(ctx, query, page.UserID, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch conversations (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res.Conversations = []model.Conversation{}
+	for rows.Next() {
+		var conversation model.Conversation
+		if err := rows.Scan(conversationFields(&conversation)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan conversations err: ")
+			return nil, err
+		}
+		res.Conversations = append(res.Conversations, conversation)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalPages = int(math.Ceil(float64(res.TotalConversations) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Conversations)
+	return &res, nil
+}
+
+// CreateMessageRepo stores the message, moves the conversation to the top of
+// both members' lists and counts it as unread for the recipient. Sending also
+// marks the conversation read for the sender.
+func (r *MessageRepo) CreateMessageRepo(ctx context.Context, tx pgx.Tx, message *model.Message) error {
+	query := `
+		INSERT INTO messages (message_id, conversation_id, sender_id, content, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := tx.Exec(ctx, query,
+		message.MessageID,
+		message.ConversationID,
+		message.SenderID,
+		message.Content,
+		message.CreatedAt,
+	)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create message (db err): ")
+		return err
+	}
+	query = `
+		UPDATE conversations
+		SET last_message_at = $2
+		WHERE conversation_id = $1
+	`
+	if _, err := tx.Exec(ctx, query, message.ConversationID, message.CreatedAt); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update conversation (db err): ")
+		return err
+	}
+	query = `
+		UPDATE conversation_members
+		SET unread_count = CASE WHEN user_id = $2 THEN 0 ELSE unread_count + 1 END,
+			last_read_at = CASE WHEN user_id = $2 THEN $3 ELSE last_read_at END
+		WHERE conversation_id = $1
+	`
+	if _, err := tx.Exec(ctx, query, message.ConversationID, message.SenderID, message.CreatedAt); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update unread counts (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *MessageRepo) GetMessagesRepo(ctx context.Context, tx pgx.Tx, page *model.MessagesPageReq) (*model.MessagesPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM messages
+		WHERE conversation_id = $1
+	`
+	var totalMessages int
+	if err := tx.QueryRow(ctx, count, page.ConversationID).Scan(&totalMessages); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count messages (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT m.message_id, m.conversation_id, m.sender_id, m.content, COALESCE(rm.last_read_at >= m.created_at, false), m.created_at
+		FROM messages m
+		JOIN conversation_members rm ON rm.conversation_id = m.conversation_id AND rm.user_id <> m.sender_id
+		WHERE m.conversation_id = $1
+		ORDER BY m.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := tx.Query(ctx, query, page.ConversationID, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch messages (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.MessagesPageRes
+	res.Messages = []model.Message{}
+	for rows.Next() {
+		var message model.Message
+		err := rows.Scan(
+			&message.MessageID,
+			&message.ConversationID,
+			&message.SenderID,
+			&message.Content,
+			&message.Read,
+			&message.CreatedAt,
+		)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan messages err: ")
+			return nil, err
+		}
+		res.Messages = append(res.Messages, message)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalMessages = totalMessages
+	res.TotalPages = int(math.Ceil(float64(res.TotalMessages) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Messages)
+	return &res, nil
+}
+func (r *MessageRepo) MarkConversationReadRepo(ctx context.Context, tx pgx.Tx, conversationID, userID uuid.UUID, readAt time.Time) error {
+	query := `
+		UPDATE conversation_members
+		SET unread_count = 0,
+			last_read_at = $3
+		WHERE conversation_id = $1 AND user_id = $2
+	`
+	if _, err := tx.Exec(ctx, query, conversationID, userID, readAt); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to mark conversation read (db err): ")
+		return err
+	}
+	return nil
+}
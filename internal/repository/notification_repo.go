@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"math"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type NotificationRepoImpl interface {
+	CreateNotificationRepo(ctx context.Context, tx pgx.Tx, notification *model.Notification) error
+	GetNotificationsRepo(ctx context.Context, tx pgx.Tx, page *model.NotificationsPageReq) (*model.NotificationsPageRes, error)
+	MarkNotificationReadRepo(ctx context.Context, tx pgx.Tx, userID, notificationID uuid.UUID) error
+	MarkAllNotificationsReadRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (int64, error)
+	GetNotificationChannelRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, kind model.NotificationType) (model.NotificationChannel, error)
+	GetNotificationPreferencesRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (model.NotificationPreferences, error)
+	SetNotificationPreferencesRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, prefs model.NotificationPreferences) error
+}
+type NotificationRepo struct{}
+
+func NewNotificationRepository() NotificationRepoImpl {
+	return &NotificationRepo{}
+}
+
+func (r *NotificationRepo) CreateNotificationRepo(ctx context.Context, tx pgx.Tx, notification *model.Notification) error {
+	query := `
+		INSERT INTO notifications (notification_id, user_id, type, channel, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := tx.Exec(ctx, query,
+		notification.NotificationID,
+		notification.UserID,
+		notification.Type,
+		notification.Channel,
+		notification.Payload,
+		notification.CreatedAt,
+	)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create notification (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *NotificationRepo) GetNotificationsRepo(ctx context.Context, tx pgx.Tx, page *model.NotificationsPageReq) (*model.NotificationsPageRes, error) {
+	count := `
+		SELECT COUNT (*) FILTER (WHERE NOT $2 OR read_at IS NULL),
+			COUNT (*) FILTER (WHERE read_at IS NULL)
+		FROM notifications
+		WHERE user_id = $1
+	`
+	var res model.NotificationsPageRes
+	err := tx.QueryRow(ctx, count, page.UserID, page.UnreadOnly).Scan(&res.TotalNotifications, &res.TotalUnread)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count notifications (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT notification_id, user_id, type, channel, payload, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := tx.Query(ctx, query, page.UserID, page.UnreadOnly, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch notifications (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res.Notifications = []model.Notification{}
+	for rows.Next() {
+		var notification model.Notification
+		err := rows.Scan(
+			&notification.NotificationID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.Channel,
+			&notification.Payload,
+			&notification.ReadAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan notifications err: ")
+			return nil, err
+		}
+		res.Notifications = append(res.Notifications, notification)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalPages = int(math.Ceil(float64(res.TotalNotifications) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Notifications)
+	return &res, nil
+}
+
+// MarkNotificationReadRepo keeps the first read_at, so marking twice is
+// harmless.
+func (r *NotificationRepo) MarkNotificationReadRepo(ctx context.Context, tx pgx.Tx, userID, notificationID uuid.UUID) error {
+	query := `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, NOW())
+		WHERE notification_id = $1 AND user_id = $2
+	`
+	tag, err := tx.Exec(ctx, query, notificationID, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to mark notification read (db err): ")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.NotFound("notification not found")
+	}
+	return nil
+}
+func (r *NotificationRepo) MarkAllNotificationsReadRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (int64, error) {
+	query := `
+		UPDATE notifications
+		SET read_at = NOW()
+		WHERE user_id = $1 AND read_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, query, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to mark notifications read (db err): ")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+func (r *NotificationRepo) GetNotificationChannelRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, kind model.NotificationType) (model.NotificationChannel, error) {
+	query := `
+		SELECT channel
+		FROM notification_preferences
+		WHERE user_id = $1 AND type = $2
+	`
+	var channel model.NotificationChannel
+	err := tx.QueryRow(ctx, query, userID, kind).Scan(&channel)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return model.ChannelInApp, nil
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to get notification preference (db err): ")
+		return "", err
+	}
+	return channel, nil
+}
+func (r *NotificationRepo) GetNotificationPreferencesRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) (model.NotificationPreferences, error) {
+	query := `
+		SELECT type, channel
+		FROM notification_preferences
+		WHERE user_id = $1
+	`
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch notification preferences (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := model.DefaultNotificationPreferences()
+	for rows.Next() {
+		var kind model.NotificationType
+		var channel model.NotificationChannel
+		if err := rows.Scan(&kind, &channel); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan notification preferences err: ")
+			return nil, err
+		}
+		prefs[kind] = channel
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return prefs, nil
+}
+func (r *NotificationRepo) SetNotificationPreferencesRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, prefs model.NotificationPreferences) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, type, channel)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, type) DO UPDATE SET channel = EXCLUDED.channel
+	`
+	for kind, channel := range prefs {
+		if _, err := tx.Exec(ctx, query, userID, kind, channel); err != nil {
+			helper.ErrMsgCtx(ctx, err, "failed to set notification preference (db err): ")
+			return err
+		}
+	}
+	return nil
+}
@@ -2,27 +2,55 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"math"
+	"time"
 
 	"github.com/bagasadiii/buy-n-con/helper"
 	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
 type PostRepoImpl interface {
 	CreatePostRepo(ctx context.Context, tx pgx.Tx, new *model.Post) error
-    GetPostByIDRepo(ctx context.Context, tx pgx.Tx, data *model.GetPostInput) (*model.Post, error)
-    GetAllPostRepo(ctx context.Context, tx pgx.Tx, page *model.PostsPageReq)(*model.PostsPageRes, error)
-    UpdatePostRepo(ctx context.Context, tx pgx.Tx, post *model.UpdatePostInput) (*model.Post, error)
-    DeletePostRepo(ctx context.Context, tx pgx.Tx, post *model.GetPostInput) error
+	GetPostByIDRepo(ctx context.Context, tx pgx.Tx, data *model.GetPostInput) (*model.Post, error)
+	GetAllPostRepo(ctx context.Context, tx pgx.Tx, page *model.PostsPageReq) (*model.PostsPageRes, error)
+	UpdatePostRepo(ctx context.Context, tx pgx.Tx, post *model.Post, version int) (*model.Post, error)
+	DeletePostRepo(ctx context.Context, tx pgx.Tx, post *model.GetPostInput, version int) error
+	GetDeletedPostsRepo(ctx context.Context, tx pgx.Tx, page *model.PostsPageReq) (*model.PostsPageRes, error)
+	RestorePostRepo(ctx context.Context, tx pgx.Tx, post *model.GetPostInput) (*model.Post, error)
+	PurgeDeletedPostsRepo(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error)
+	AdjustCommentCountRepo(ctx context.Context, tx pgx.Tx, postID uuid.UUID, delta int) error
+	GetFeedPostsRepo(ctx context.Context, tx pgx.Tx, req *model.FeedReq) ([]model.Post, error)
+	CheckPostItemsRepo(ctx context.Context, tx pgx.Tx, post *model.Post) error
+	SetPostItemsRepo(ctx context.Context, tx pgx.Tx, post *model.Post) error
+	GetPostItemsRepo(ctx context.Context, tx pgx.Tx, postIDs []uuid.UUID) (map[uuid.UUID][]model.ItemCard, error)
 }
 type PostRepo struct{}
 
-func NewPostRepository()PostRepoImpl {
+// postFields returns the scan targets for the post columns every query
+// selects, in order: post_id, owner, content, created_at, updated_at,
+// user_id, version, comment_count, reaction_counts. Extra targets are
+// appended.
+func postFields(post *model.Post, extra ...any) []any {
+	fields := []any{
+		&post.PostID,
+		&post.Owner,
+		&post.Content,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+		&post.UserID,
+		&post.Version,
+		&post.CommentCount,
+		&post.ReactionCounts,
+	}
+	return append(fields, extra...)
+}
+
+func NewPostRepository() PostRepoImpl {
 	return &PostRepo{}
 }
-func (r *PostRepo) CreatePostRepo(ctx context.Context, tx pgx.Tx, new *model.Post)error{
+func (r *PostRepo) CreatePostRepo(ctx context.Context, tx pgx.Tx, new *model.Post) error {
 	query := `
 		INSERT INTO posts (post_id, content, owner, created_at, updated_at, user_id)
 		VALUES ($1, $2, $3, $4, $5,$6)
@@ -36,61 +64,56 @@ func (r *PostRepo) CreatePostRepo(ctx context.Context, tx pgx.Tx, new *model.Pos
 		new.UserID,
 	)
 	if err != nil {
-		helper.ErrMsg(err, "failed to exec command")
+		helper.ErrMsgCtx(ctx, err, "failed to exec command")
 		return err
 	}
 	return nil
 }
-func(r *PostRepo) GetPostByIDRepo(ctx context.Context, tx pgx.Tx, data *model.GetPostInput)(*model.Post, error){
+func (r *PostRepo) GetPostByIDRepo(ctx context.Context, tx pgx.Tx, data *model.GetPostInput) (*model.Post, error) {
 	query := `
-		SELECT post_id, content, owner, created_at, updated_at, user_id
+		SELECT post_id, owner, content, created_at, updated_at, user_id, version, comment_count, reaction_counts,
+			(SELECT reaction FROM post_reactions pr WHERE pr.post_id = posts.post_id AND pr.user_id = $3)
 		FROM posts
-		WHERE post_id = $1 AND owner = $2
+		WHERE post_id = $1 AND owner = $2 AND deleted_at IS NULL
 	`
 	var post model.Post
-	row := tx.QueryRow(ctx, query, data.PostID, data.Owner)
-	err := row.Scan(
-		&post.PostID,
-		&post.Content,
-		&post.Owner,
-		&post.CreatedAt,
-		&post.UpdatedAt,
-		&post.UserID,
-	)
+	row := tx.QueryRow(ctx, query, data.PostID, data.Owner, data.ViewerID)
+	err := row.Scan(postFields(&post, &post.MyReaction)...)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("post not found")
 		}
-		helper.ErrMsg(err, "failed to fetch post (db error): ")
+		helper.ErrMsgCtx(ctx, err, "failed to fetch post (db error): ")
 		return nil, err
 	}
 	return &post, nil
 }
-func(r *PostRepo)GetAllPostRepo(ctx context.Context, tx pgx.Tx, page *model.PostsPageReq)(*model.PostsPageRes, error){
+func (r *PostRepo) GetAllPostRepo(ctx context.Context, tx pgx.Tx, page *model.PostsPageReq) (*model.PostsPageRes, error) {
 	count := `
 		SELECT COUNT (*)
 		FROM posts
-		WHERE owner = $1
+		WHERE owner = $1 AND deleted_at IS NULL
 	`
-	var totalPosts int 
+	var totalPosts int
 	err := tx.QueryRow(ctx, count, page.Username).Scan(&totalPosts)
 	if err != nil {
-		helper.ErrMsg(err, "failed to count posts (db err)")
+		helper.ErrMsgCtx(ctx, err, "failed to count posts (db err)")
 		return nil, err
 	}
 	query := `
-		SELECT post_id, owner, content, created_at, updated_at, user_id
+		SELECT post_id, owner, content, created_at, updated_at, user_id, version, comment_count, reaction_counts,
+			(SELECT reaction FROM post_reactions pr WHERE pr.post_id = posts.post_id AND pr.user_id = $4)
 		FROM posts
-		WHERE owner = $1
+		WHERE owner = $1 AND deleted_at IS NULL
 		ORDER BY created_at DESC
 		LIMIT $2 OFFSET $3
 	`
-	rows, err := tx.Query(ctx, query, page.Username, page.Limit, page.Offset)
+	rows, err := tx.Query(ctx, query, page.Username, page.Limit, page.Offset, page.ViewerID)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("post not found")
 		}
-		helper.ErrMsg(err, "failed to fetch post (db error): ")
+		helper.ErrMsgCtx(ctx, err, "failed to fetch post (db error): ")
 		return nil, err
 	}
 	defer rows.Close()
@@ -99,37 +122,34 @@ func(r *PostRepo)GetAllPostRepo(ctx context.Context, tx pgx.Tx, page *model.Post
 	res.Posts = []model.Post{}
 	for rows.Next() {
 		var post model.Post
-		err := rows.Scan(
-			&post.PostID,
-			&post.Owner,
-			&post.Content,
-			&post.CreatedAt,
-			&post.UpdatedAt,
-			&post.UserID,
-		)
+		err := rows.Scan(postFields(&post, &post.MyReaction)...)
 		if err != nil {
-			helper.ErrMsg(err, "scan posts err: ")
+			helper.ErrMsgCtx(ctx, err, "scan posts err: ")
 			return nil, err
 		}
 		res.Posts = append(res.Posts, post)
 	}
 	if rows.Err() != nil {
-		helper.ErrMsg(rows.Err(), "iteration rows err: ")
-		return nil, err
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
 	}
 	res.TotalPosts = totalPosts
 	res.TotalPages = int(math.Ceil(float64(res.TotalPosts) / float64(page.Limit)))
-	res.Current = (page.Offset/page.Limit) + 1
+	res.Current = (page.Offset / page.Limit) + 1
 	res.PageSize = len(res.Posts)
 	return &res, nil
 }
-func(r *PostRepo)UpdatePostRepo(ctx context.Context, tx pgx.Tx, post *model.UpdatePostInput)(*model.Post, error){
+
+// UpdatePostRepo only writes when the row is still at the given version, so
+// ErrNoRows here means someone else changed the post after it was read.
+func (r *PostRepo) UpdatePostRepo(ctx context.Context, tx pgx.Tx, post *model.Post, version int) (*model.Post, error) {
 	query := `
 		UPDATE posts
 		SET content = $1,
-			updated_at = $2
-		WHERE post_id = $3 AND owner = $4
-		RETURNING post_id, owner, content, created_at, updated_at
+			updated_at = $2,
+			version = version + 1
+		WHERE post_id = $3 AND owner = $4 AND version = $5 AND deleted_at IS NULL
+		RETURNING post_id, owner, content, created_at, updated_at, user_id, version, comment_count, reaction_counts
 	`
 	var updatedPost model.Post
 	err := tx.QueryRow(ctx, query,
@@ -137,34 +157,256 @@ func(r *PostRepo)UpdatePostRepo(ctx context.Context, tx pgx.Tx, post *model.Upda
 		post.UpdatedAt,
 		post.PostID,
 		post.Owner,
-	).Scan(
-		&updatedPost.PostID,
-		&updatedPost.Owner,
-		&updatedPost.Content,
-		&updatedPost.CreatedAt,
-		&updatedPost.UpdatedAt,
-	)
+		version,
+	).Scan(postFields(&updatedPost)...)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.ErrVersionConflict
 		}
-		helper.ErrMsg(err, "failed to update post (db err): ")
+		helper.ErrMsgCtx(ctx, err, "failed to update post (db err): ")
 		return nil, err
 	}
 	return &updatedPost, nil
 }
-func(r *PostRepo)DeletePostRepo(ctx context.Context, tx pgx.Tx, post *model.GetPostInput)error{
+func (r *PostRepo) DeletePostRepo(ctx context.Context, tx pgx.Tx, post *model.GetPostInput, version int) error {
+	query := `
+		UPDATE posts
+		SET deleted_at = $1,
+			version = version + 1
+		WHERE post_id = $2 AND owner = $3 AND version = $4 AND deleted_at IS NULL
+	`
+	tag, err := tx.Exec(ctx, query, time.Now(), post.PostID, post.Owner, version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete post (db err): ")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.ErrVersionConflict
+	}
+	return nil
+}
+func (r *PostRepo) GetDeletedPostsRepo(ctx context.Context, tx pgx.Tx, page *model.PostsPageReq) (*model.PostsPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM posts
+		WHERE owner = $1 AND deleted_at IS NOT NULL
+	`
+	var totalPosts int
+	err := tx.QueryRow(ctx, count, page.Username).Scan(&totalPosts)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count deleted posts (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT post_id, owner, content, created_at, updated_at, user_id, version, comment_count, reaction_counts, deleted_at
+		FROM posts
+		WHERE owner = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := tx.Query(ctx, query, page.Username, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch deleted posts (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.PostsPageRes
+	res.Posts = []model.Post{}
+	for rows.Next() {
+		var post model.Post
+		err := rows.Scan(postFields(&post, &post.DeletedAt)...)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan deleted posts err: ")
+			return nil, err
+		}
+		res.Posts = append(res.Posts, post)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalPosts = totalPosts
+	res.TotalPages = int(math.Ceil(float64(res.TotalPosts) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Posts)
+	return &res, nil
+}
+func (r *PostRepo) RestorePostRepo(ctx context.Context, tx pgx.Tx, post *model.GetPostInput) (*model.Post, error) {
+	query := `
+		UPDATE posts
+		SET deleted_at = NULL,
+			updated_at = $1,
+			version = version + 1
+		WHERE post_id = $2 AND owner = $3 AND deleted_at IS NOT NULL
+		RETURNING post_id, owner, content, created_at, updated_at, user_id, version, comment_count, reaction_counts
+	`
+	var restored model.Post
+	err := tx.QueryRow(ctx, query, time.Now(), post.PostID, post.Owner).Scan(postFields(&restored)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("post not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to restore post (db err): ")
+		return nil, err
+	}
+	return &restored, nil
+}
+func (r *PostRepo) PurgeDeletedPostsRepo(ctx context.Context, tx pgx.Tx, before time.Time) (int64, error) {
 	query := `
 		DELETE FROM posts
-        WHERE post_id = $1 AND owner = $2
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+	`
+	tag, err := tx.Exec(ctx, query, before)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to purge deleted posts (db err): ")
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+func (r *PostRepo) AdjustCommentCountRepo(ctx context.Context, tx pgx.Tx, postID uuid.UUID, delta int) error {
+	query := `
+		UPDATE posts
+		SET comment_count = GREATEST(comment_count + $1, 0)
+		WHERE post_id = $2
+	`
+	_, err := tx.Exec(ctx, query, delta, postID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update comment count (db err): ")
+		return err
+	}
+	return nil
+}
+
+// GetFeedPostsRepo reads the newest posts of everyone req.UserID follows,
+// starting after req.Cursor. Each followee contributes at most req.Limit rows
+// through idx_posts_feed, so the cost stays bounded for large follow lists.
+func (r *PostRepo) GetFeedPostsRepo(ctx context.Context, tx pgx.Tx, req *model.FeedReq) ([]model.Post, error) {
+	query := `
+		SELECT p.post_id, p.owner, p.content, p.created_at, p.updated_at, p.user_id, p.version, p.comment_count, p.reaction_counts,
+			(SELECT reaction FROM post_reactions pr WHERE pr.post_id = p.post_id AND pr.user_id = $1)
+		FROM follows f
+		CROSS JOIN LATERAL (
+			SELECT *
+			FROM posts
+			WHERE posts.user_id = f.followee_id AND posts.deleted_at IS NULL
+				AND ($2::timestamptz IS NULL OR (posts.created_at, posts.post_id) < ($2, $3::uuid))
+			ORDER BY posts.created_at DESC, posts.post_id DESC
+			LIMIT $4
+		) p
+		WHERE f.follower_id = $1
+		ORDER BY p.created_at DESC, p.post_id DESC
+		LIMIT $4
+	`
+	var cursorAt *time.Time
+	var cursorID *uuid.UUID
+	if req.Cursor != nil {
+		cursorAt, cursorID = &req.Cursor.CreatedAt, &req.Cursor.ID
+	}
+	rows, err := tx.Query(ctx, query, req.UserID, cursorAt, cursorID, req.Limit)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch feed posts (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []model.Post{}
+	for rows.Next() {
+		var post model.Post
+		if err := rows.Scan(postFields(&post, &post.MyReaction)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan feed posts err: ")
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return posts, nil
+}
+
+// CheckPostItemsRepo makes sure every item in post.ItemIDs belongs to the
+// post's author, is not deleted and is publicly listed, since drafts and
+// archived items would only show as removed cards. It runs before any
+// write so a rejected attachment leaves nothing behind.
+func (r *PostRepo) CheckPostItemsRepo(ctx context.Context, tx pgx.Tx, post *model.Post) error {
+	if len(post.ItemIDs) == 0 {
+		return nil
+	}
+	query := `
+		SELECT COUNT (*)
+		FROM items
+		WHERE item_id = ANY($1) AND user_id = $2 AND deleted_at IS NULL
+			AND status IN ('active', 'reserved', 'sold')
+	`
+	var found int
+	if err := tx.QueryRow(ctx, query, post.ItemIDs, post.UserID).Scan(&found); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to check post items (db err): ")
+		return err
+	}
+	if found != len(post.ItemIDs) {
+		return model.ErrItemNotAttachable
+	}
+	return nil
+}
+
+// SetPostItemsRepo replaces the items attached to post with post.ItemIDs,
+// keeping the order they were given in.
+func (r *PostRepo) SetPostItemsRepo(ctx context.Context, tx pgx.Tx, post *model.Post) error {
+	_, err := tx.Exec(ctx, `DELETE FROM post_items WHERE post_id = $1`, post.PostID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to clear post items (db err): ")
+		return err
+	}
+	if len(post.ItemIDs) == 0 {
+		return nil
+	}
+	query := `
+		INSERT INTO post_items (post_id, item_id, position)
+		SELECT $1, t.item_id, t.position
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS t(item_id, position)
+	`
+	_, err = tx.Exec(ctx, query, post.PostID, post.ItemIDs)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to attach post items (db err): ")
+		return err
+	}
+	return nil
+}
+
+// GetPostItemsRepo loads the item cards of several posts at once, keyed by
+// post, so list endpoints stay at one extra query per page.
+func (r *PostRepo) GetPostItemsRepo(ctx context.Context, tx pgx.Tx, postIDs []uuid.UUID) (map[uuid.UUID][]model.ItemCard, error) {
+	query := `
+		SELECT pi.post_id, i.item_id, i.name, i.quantity, i.price, i.status, i.deleted_at IS NOT NULL
+		FROM post_items pi
+		JOIN items i ON i.item_id = pi.item_id
+		WHERE pi.post_id = ANY($1)
+		ORDER BY pi.post_id, pi.position
 	`
-	_, err := tx.Exec(ctx, query, post.PostID, post.Owner)
-    if err != nil {
-        if err == pgx.ErrNoRows {
-            return errors.New("item not found")
-        }
-        helper.ErrMsg(err, "failed to delete post (db err): ")
-        return err
-    }
-    return nil
-}
\ No newline at end of file
+	rows, err := tx.Query(ctx, query, postIDs)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch post items (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := make(map[uuid.UUID][]model.ItemCard, len(postIDs))
+	for rows.Next() {
+		var postID uuid.UUID
+		var item model.ItemResp
+		var deleted bool
+		err := rows.Scan(&postID, &item.ItemID, &item.Name, &item.Quantity, &item.Price, &item.Status, &deleted)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan post items err: ")
+			return nil, err
+		}
+		cards[postID] = append(cards[postID], model.NewItemCard(&item, deleted))
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return cards, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ReactionRepoImpl interface {
+	GetReactionRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID, userID uuid.UUID) (*model.Reaction, error)
+	UpsertReactionRepo(ctx context.Context, tx pgx.Tx, reaction *model.ReactionRecord) error
+	DeleteReactionRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID, userID uuid.UUID) error
+	AdjustReactionCountRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID uuid.UUID, reaction model.Reaction, delta int) (map[model.Reaction]int, error)
+}
+type ReactionRepo struct{}
+
+func NewReactionRepository() ReactionRepoImpl {
+	return &ReactionRepo{}
+}
+
+// reactionTables maps a target to its table, the reactions table and the key
+// column both share. Queries are built from these names only, never from
+// request input.
+func reactionTables(target model.ReactionTarget) (table, reactions, key string, err error) {
+	switch target {
+	case model.ReactionTargetPost:
+		return "posts", "post_reactions", "post_id", nil
+	case model.ReactionTargetItem:
+		return "items", "item_reactions", "item_id", nil
+	}
+	return "", "", "", model.Invalid(fmt.Sprintf("unknown reaction target %q", target))
+}
+
+// GetReactionRepo locks the target row for the rest of the transaction and
+// returns the user's current reaction on it, or nil when there is none. The
+// lock keeps two toggles by the same user from racing each other.
+func (r *ReactionRepo) GetReactionRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID, userID uuid.UUID) (*model.Reaction, error) {
+	table, reactions, key, err := reactionTables(target)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		SELECT r.reaction
+		FROM %[1]s t
+		LEFT JOIN %[2]s r ON r.%[3]s = t.%[3]s AND r.user_id = $2
+		WHERE t.%[3]s = $1 AND t.deleted_at IS NULL
+		FOR UPDATE OF t
+	`, table, reactions, key)
+	var reaction *model.Reaction
+	err = tx.QueryRow(ctx, query, targetID, userID).Scan(&reaction)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound(string(target) + " not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to get reaction (db err): ")
+		return nil, err
+	}
+	return reaction, nil
+}
+func (r *ReactionRepo) UpsertReactionRepo(ctx context.Context, tx pgx.Tx, reaction *model.ReactionRecord) error {
+	_, reactions, key, err := reactionTables(reaction.Target)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		INSERT INTO %[1]s (%[2]s, user_id, reaction, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (%[2]s, user_id)
+		DO UPDATE SET reaction = EXCLUDED.reaction, created_at = EXCLUDED.created_at
+	`, reactions, key)
+	_, err = tx.Exec(ctx, query, reaction.TargetID, reaction.UserID, reaction.Reaction, reaction.CreatedAt)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to save reaction (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *ReactionRepo) DeleteReactionRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID, userID uuid.UUID) error {
+	_, reactions, key, err := reactionTables(target)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s
+		WHERE %[2]s = $1 AND user_id = $2
+	`, reactions, key)
+	_, err = tx.Exec(ctx, query, targetID, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete reaction (db err): ")
+		return err
+	}
+	return nil
+}
+
+// AdjustReactionCountRepo shifts one entry of the denormalized
+// reaction_counts column and returns the whole map. Entries that drop to zero
+// are removed so the map only lists reactions somebody actually left.
+func (r *ReactionRepo) AdjustReactionCountRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID uuid.UUID, reaction model.Reaction, delta int) (map[model.Reaction]int, error) {
+	table, _, key, err := reactionTables(target)
+	if err != nil {
+		return nil, err
+	}
+	query := fmt.Sprintf(`
+		UPDATE %[1]s
+		SET reaction_counts = CASE
+			WHEN COALESCE((reaction_counts->>$1::text)::int, 0) + $2 <= 0
+				THEN reaction_counts - $1::text
+			ELSE jsonb_set(reaction_counts, ARRAY[$1::text], to_jsonb(COALESCE((reaction_counts->>$1::text)::int, 0) + $2))
+		END
+		WHERE %[2]s = $3
+		RETURNING reaction_counts
+	`, table, key)
+	var counts map[model.Reaction]int
+	err = tx.QueryRow(ctx, query, reaction, delta, targetID).Scan(&counts)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound(string(target) + " not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to update reaction counts (db err): ")
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type TagRepoImpl interface {
+	SetPostTagsRepo(ctx context.Context, tx pgx.Tx, postID uuid.UUID, tags []string) error
+	SetPostMentionsRepo(ctx context.Context, tx pgx.Tx, post *model.Post, usernames []string) ([]uuid.UUID, error)
+	GetPostsByTagRepo(ctx context.Context, tx pgx.Tx, page *model.TagPostsPageReq) (*model.PostsPageRes, error)
+	GetTrendingTagsRepo(ctx context.Context, tx pgx.Tx, req *model.TrendingTagsReq) ([]model.TrendingTag, error)
+}
+type TagRepo struct{}
+
+func NewTagRepository() TagRepoImpl {
+	return &TagRepo{}
+}
+
+// SetPostTagsRepo syncs the post's tags with tags. Tags the post already had
+// keep their original created_at, so editing a post does not make an old tag
+// trend again.
+func (r *TagRepo) SetPostTagsRepo(ctx context.Context, tx pgx.Tx, postID uuid.UUID, tags []string) error {
+	query := `
+		DELETE FROM post_tags
+		WHERE post_id = $1 AND NOT (tag = ANY($2))
+	`
+	if _, err := tx.Exec(ctx, query, postID, tags); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to clear post tags (db err): ")
+		return err
+	}
+	query = `
+		INSERT INTO post_tags (post_id, tag, created_at)
+		SELECT $1, t.tag, $3
+		FROM unnest($2::text[]) AS t(tag)
+		ON CONFLICT (post_id, tag) DO NOTHING
+	`
+	if _, err := tx.Exec(ctx, query, postID, tags, time.Now()); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to save post tags (db err): ")
+		return err
+	}
+	return nil
+}
+
+// SetPostMentionsRepo syncs the users mentioned in post and returns only the
+// ones mentioned for the first time. Unknown usernames and the author are
+// skipped.
+func (r *TagRepo) SetPostMentionsRepo(ctx context.Context, tx pgx.Tx, post *model.Post, usernames []string) ([]uuid.UUID, error) {
+	query := `
+		DELETE FROM post_mentions pm
+		USING users u
+		WHERE pm.post_id = $1 AND u.user_id = pm.user_id AND NOT (u.username = ANY($2))
+	`
+	if _, err := tx.Exec(ctx, query, post.PostID, usernames); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to clear post mentions (db err): ")
+		return nil, err
+	}
+	query = `
+		INSERT INTO post_mentions (post_id, user_id)
+		SELECT $1, u.user_id
+		FROM users u
+		WHERE u.username = ANY($2) AND u.user_id <> $3
+		ON CONFLICT (post_id, user_id) DO NOTHING
+		RETURNING user_id
+	`
+	rows, err := tx.Query(ctx, query, post.PostID, usernames, post.UserID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to save post mentions (db err): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	mentioned := []uuid.UUID{}
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan mentions err: ")
+			return nil, err
+		}
+		mentioned = append(mentioned, userID)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return mentioned, nil
+}
+func (r *TagRepo) GetPostsByTagRepo(ctx context.Context, tx pgx.Tx, page *model.TagPostsPageReq) (*model.PostsPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM post_tags pt
+		JOIN posts ON posts.post_id = pt.post_id
+		WHERE pt.tag = $1 AND posts.deleted_at IS NULL
+	`
+	var totalPosts int
+	if err := tx.QueryRow(ctx, count, page.Tag).Scan(&totalPosts); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count tagged posts (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT posts.post_id, posts.owner, posts.content, posts.created_at, posts.updated_at, posts.user_id, posts.version, posts.comment_count, posts.reaction_counts,
+			(SELECT reaction FROM post_reactions pr WHERE pr.post_id = posts.post_id AND pr.user_id = $4)
+		FROM post_tags pt
+		JOIN posts ON posts.post_id = pt.post_id
+		WHERE pt.tag = $1 AND posts.deleted_at IS NULL
+		ORDER BY posts.created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := tx.Query(ctx, query, page.Tag, page.Limit, page.Offset, page.ViewerID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch tagged posts (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res model.PostsPageRes
+	res.Posts = []model.Post{}
+	for rows.Next() {
+		var post model.Post
+		if err := rows.Scan(postFields(&post, &post.MyReaction)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan tagged posts err: ")
+			return nil, err
+		}
+		res.Posts = append(res.Posts, post)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalPosts = totalPosts
+	res.TotalPages = int(math.Ceil(float64(res.TotalPosts) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Posts)
+	return &res, nil
+}
+
+// GetTrendingTagsRepo ranks tags by how many live posts picked them up since
+// req.Since.
+func (r *TagRepo) GetTrendingTagsRepo(ctx context.Context, tx pgx.Tx, req *model.TrendingTagsReq) ([]model.TrendingTag, error) {
+	query := `
+		SELECT pt.tag, COUNT (*) AS post_count
+		FROM post_tags pt
+		JOIN posts ON posts.post_id = pt.post_id
+		WHERE pt.created_at >= $1 AND posts.deleted_at IS NULL
+		GROUP BY pt.tag
+		ORDER BY post_count DESC, pt.tag
+		LIMIT $2
+	`
+	rows, err := tx.Query(ctx, query, req.Since, req.Limit)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch trending tags (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []model.TrendingTag{}
+	for rows.Next() {
+		var tag model.TrendingTag
+		if err := rows.Scan(&tag.Tag, &tag.PostCount); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan trending tags err: ")
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return tags, nil
+}
@@ -2,7 +2,6 @@ package repository
 
 import (
 	"context"
-	"errors"
 
 	"github.com/bagasadiii/buy-n-con/helper"
 	"github.com/bagasadiii/buy-n-con/internal/model"
@@ -11,36 +10,37 @@ import (
 )
 
 type UserRepoImpl interface {
-	RegisterRepo(ctx context.Context,user *model.User)error
-	LoginRepo(ctx context.Context, input *model.LoginInput)(*model.User, error)
-	GetUserRepo(ctx context.Context, username string)(*model.UserResponse, error)
+	RegisterRepo(ctx context.Context, user *model.User) error
+	LoginRepo(ctx context.Context, input *model.LoginInput) (*model.User, error)
+	GetUserRepo(ctx context.Context, username string) (*model.UserResponse, error)
 }
 type UserRepository struct {
 	db *pgxpool.Pool
 }
-func NewUserRepository(db *pgxpool.Pool)UserRepoImpl{
-	return &UserRepository{db:db}
+
+func NewUserRepository(db *pgxpool.Pool) UserRepoImpl {
+	return &UserRepository{db: db}
 }
-func(r *UserRepository)RegisterRepo(ctx context.Context,user *model.User)error{
+func (r *UserRepository) RegisterRepo(ctx context.Context, user *model.User) error {
 	query := `
 		INSERT INTO users (user_id, username, email, password, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6);
 	`
-	_, err := r.db.Exec(ctx, query, user.UserID, 
-		user.Username, 
-		user.Email, 
-		user.Password, 
-		user.CreatedAt, 
+	_, err := r.db.Exec(ctx, query, user.UserID,
+		user.Username,
+		user.Email,
+		user.Password,
+		user.CreatedAt,
 		user.UpdatedAt,
 	)
 	if err != nil {
-		return helper.ErrMsg(err, "failed to create user: ")
+		return helper.ErrMsgCtx(ctx, err, "failed to create user: ")
 	}
 
-	helper.SuccessMsg("user created")
+	helper.SuccessMsgCtx(ctx, "user created")
 	return nil
 }
-func(r *UserRepository)LoginRepo(ctx context.Context, input *model.LoginInput)(*model.User, error){
+func (r *UserRepository) LoginRepo(ctx context.Context, input *model.LoginInput) (*model.User, error) {
 	query := `
 		SELECT user_id, password, email
 		FROM users
@@ -53,18 +53,18 @@ func(r *UserRepository)LoginRepo(ctx context.Context, input *model.LoginInput)(*
 		&user.Email,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.ErrInvalidCredentials
 		}
-		helper.ErrMsg(err, "failed to find data: ")
+		helper.ErrMsgCtx(ctx, err, "failed to find data: ")
 		return nil, err
 	}
-	helper.SuccessMsg("login successful")
+	helper.SuccessMsgCtx(ctx, "login successful")
 	return &user, nil
 }
-func(r *UserRepository)GetUserRepo(ctx context.Context, username string)(*model.UserResponse, error){
+func (r *UserRepository) GetUserRepo(ctx context.Context, username string) (*model.UserResponse, error) {
 	query := `
-		SELECT user_id, username, email, created_at, updated_at
+		SELECT user_id, username, email, follower_count, following_count, created_at, updated_at
 		FROM users
 		WHERE username = $1
 	`
@@ -73,15 +73,17 @@ func(r *UserRepository)GetUserRepo(ctx context.Context, username string)(*model.
 		&user.UserID,
 		&user.Username,
 		&user.Email,
+		&user.FollowerCount,
+		&user.FollowingCount,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 	if err != nil {
-		if err == pgx.ErrNoRows{
-			return nil, errors.New("no data found")
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("user not found")
 		}
-		helper.ErrMsg(err, "failed to find data: ")
+		helper.ErrMsgCtx(ctx, err, "failed to find data: ")
 		return nil, err
 	}
 	return &user, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type WebhookRepoImpl interface {
+	CreateWebhookRepo(ctx context.Context, tx pgx.Tx, webhook *model.Webhook) error
+	GetWebhooksRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) ([]model.Webhook, error)
+	DeleteWebhookRepo(ctx context.Context, tx pgx.Tx, userID, webhookID uuid.UUID) error
+	GetSubscribedWebhooksRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event model.WebhookEvent) ([]uuid.UUID, error)
+	CreateDeliveryRepo(ctx context.Context, tx pgx.Tx, delivery *model.WebhookDelivery) error
+	GetDeliveriesRepo(ctx context.Context, tx pgx.Tx, page *model.WebhookDeliveriesPageReq) (*model.WebhookDeliveriesPageRes, error)
+	GetDeliveryRepo(ctx context.Context, tx pgx.Tx, userID, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+	ClaimDueDeliveriesRepo(ctx context.Context, tx pgx.Tx, limit int, leaseUntil time.Time) ([]model.WebhookDelivery, error)
+	RecordAttemptRepo(ctx context.Context, tx pgx.Tx, delivery *model.WebhookDelivery) error
+}
+type WebhookRepo struct{}
+
+func NewWebhookRepository() WebhookRepoImpl {
+	return &WebhookRepo{}
+}
+
+const deliveryColumns = `d.delivery_id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func deliveryFields(delivery *model.WebhookDelivery, extra ...any) []any {
+	fields := []any{
+		&delivery.DeliveryID,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.CreatedAt,
+		&delivery.DeliveredAt,
+	}
+	return append(fields, extra...)
+}
+
+func (r *WebhookRepo) CreateWebhookRepo(ctx context.Context, tx pgx.Tx, webhook *model.Webhook) error {
+	query := `
+		INSERT INTO webhooks (webhook_id, user_id, url, events, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	events := make([]string, len(webhook.Events))
+	for i, event := range webhook.Events {
+		events[i] = string(event)
+	}
+	_, err := tx.Exec(ctx, query,
+		webhook.WebhookID,
+		webhook.UserID,
+		webhook.URL,
+		events,
+		webhook.Secret,
+		webhook.CreatedAt,
+	)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create webhook (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *WebhookRepo) GetWebhooksRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID) ([]model.Webhook, error) {
+	query := `
+		SELECT webhook_id, user_id, url, events, created_at
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := tx.Query(ctx, query, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch webhooks (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+	for rows.Next() {
+		var webhook model.Webhook
+		var events []string
+		if err := rows.Scan(&webhook.WebhookID, &webhook.UserID, &webhook.URL, &events, &webhook.CreatedAt); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan webhooks err: ")
+			return nil, err
+		}
+		for _, event := range events {
+			webhook.Events = append(webhook.Events, model.WebhookEvent(event))
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return webhooks, nil
+}
+func (r *WebhookRepo) DeleteWebhookRepo(ctx context.Context, tx pgx.Tx, userID, webhookID uuid.UUID) error {
+	query := `
+		DELETE FROM webhooks
+		WHERE webhook_id = $1 AND user_id = $2
+	`
+	tag, err := tx.Exec(ctx, query, webhookID, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete webhook (db err): ")
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return model.NotFound("webhook not found")
+	}
+	return nil
+}
+func (r *WebhookRepo) GetSubscribedWebhooksRepo(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event model.WebhookEvent) ([]uuid.UUID, error) {
+	query := `
+		SELECT webhook_id
+		FROM webhooks
+		WHERE user_id = $1 AND $2 = ANY (events)
+	`
+	rows, err := tx.Query(ctx, query, userID, string(event))
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch subscribed webhooks (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan webhooks err: ")
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return ids, nil
+}
+func (r *WebhookRepo) CreateDeliveryRepo(ctx context.Context, tx pgx.Tx, delivery *model.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (delivery_id, webhook_id, event, payload, status, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := tx.Exec(ctx, query,
+		delivery.DeliveryID,
+		delivery.WebhookID,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.CreatedAt,
+	)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create webhook delivery (db err): ")
+		return err
+	}
+	return nil
+}
+func (r *WebhookRepo) GetDeliveriesRepo(ctx context.Context, tx pgx.Tx, page *model.WebhookDeliveriesPageReq) (*model.WebhookDeliveriesPageRes, error) {
+	count := `
+		SELECT COUNT (*)
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.webhook_id = d.webhook_id
+		WHERE d.webhook_id = $1 AND w.user_id = $2
+	`
+	var res model.WebhookDeliveriesPageRes
+	err := tx.QueryRow(ctx, count, page.WebhookID, page.UserID).Scan(&res.TotalDeliveries)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to count webhook deliveries (db err)")
+		return nil, err
+	}
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.webhook_id = d.webhook_id
+		WHERE d.webhook_id = $1 AND w.user_id = $2
+		ORDER BY d.created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := tx.Query(ctx, query, page.WebhookID, page.UserID, page.Limit, page.Offset)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to fetch webhook deliveries (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	res.Deliveries = []model.WebhookDelivery{}
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		if err := rows.Scan(deliveryFields(&delivery)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan webhook deliveries err: ")
+			return nil, err
+		}
+		res.Deliveries = append(res.Deliveries, delivery)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	res.TotalPages = int(math.Ceil(float64(res.TotalDeliveries) / float64(page.Limit)))
+	res.Current = (page.Offset / page.Limit) + 1
+	res.PageSize = len(res.Deliveries)
+	return &res, nil
+}
+func (r *WebhookRepo) GetDeliveryRepo(ctx context.Context, tx pgx.Tx, userID, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.webhook_id = d.webhook_id
+		WHERE d.delivery_id = $1 AND d.webhook_id = $2 AND w.user_id = $3
+	`
+	var delivery model.WebhookDelivery
+	err := tx.QueryRow(ctx, query, deliveryID, webhookID, userID).Scan(deliveryFields(&delivery)...)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, model.NotFound("webhook delivery not found")
+		}
+		helper.ErrMsgCtx(ctx, err, "failed to get webhook delivery (db err): ")
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ClaimDueDeliveriesRepo picks pending deliveries that are due and pushes
+// their next attempt to leaseUntil, so other workers skip them while this
+// one sends. If the worker dies the deliveries become due again after the
+// lease.
+func (r *WebhookRepo) ClaimDueDeliveriesRepo(ctx context.Context, tx pgx.Tx, limit int, leaseUntil time.Time) ([]model.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT delivery_id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $2
+		FROM due, webhooks w
+		WHERE d.delivery_id = due.delivery_id AND w.webhook_id = d.webhook_id
+		RETURNING ` + deliveryColumns + `, w.url, w.secret
+	`
+	rows, err := tx.Query(ctx, query, limit, leaseUntil)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to claim webhook deliveries (db error): ")
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		var delivery model.WebhookDelivery
+		if err := rows.Scan(deliveryFields(&delivery, &delivery.URL, &delivery.Secret)...); err != nil {
+			helper.ErrMsgCtx(ctx, err, "scan webhook deliveries err: ")
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if rows.Err() != nil {
+		helper.ErrMsgCtx(ctx, rows.Err(), "iteration rows err: ")
+		return nil, rows.Err()
+	}
+	return deliveries, nil
+}
+func (r *WebhookRepo) RecordAttemptRepo(ctx context.Context, tx pgx.Tx, delivery *model.WebhookDelivery) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			last_status_code = $5,
+			last_error = $6,
+			delivered_at = $7
+		WHERE delivery_id = $1
+	`
+	_, err := tx.Exec(ctx, query,
+		delivery.DeliveryID,
+		delivery.Status,
+		delivery.Attempts,
+		delivery.NextAttemptAt,
+		delivery.LastStatusCode,
+		delivery.LastError,
+		delivery.DeliveredAt,
+	)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to record webhook attempt (db err): ")
+		return err
+	}
+	return nil
+}
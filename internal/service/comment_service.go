@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CommentServiceImpl interface {
+	CreateCommentService(ctx context.Context, input *model.CommentInput, getPost *model.GetPostInput) (*model.Comment, error)
+	GetCommentsService(ctx context.Context, page *model.CommentsPageReq) (*model.CommentsPageRes, error)
+	UpdateCommentService(ctx context.Context, input *model.UpdateCommentInput, getComment *model.GetCommentInput) (*model.Comment, error)
+	DeleteCommentService(ctx context.Context, getComment *model.GetCommentInput) error
+}
+type CommentService struct {
+	repo     repository.CommentRepoImpl
+	postRepo repository.PostRepoImpl
+	notifier Notifier
+	db       *pgxpool.Pool
+}
+
+func NewCommentService(repo repository.CommentRepoImpl, postRepo repository.PostRepoImpl, notifier Notifier, db *pgxpool.Pool) CommentServiceImpl {
+	return &CommentService{
+		repo:     repo,
+		postRepo: postRepo,
+		notifier: notifier,
+		db:       db,
+	}
+}
+
+func (s *CommentService) CreateCommentService(ctx context.Context, input *model.CommentInput, getPost *model.GetPostInput) (res *model.Comment, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	post, err := s.postRepo.GetPostByIDRepo(ctx, tx, getPost)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get post: ")
+		return nil, err
+	}
+	var parent *model.Comment
+	if input.ParentID != nil {
+		parent, err = s.repo.GetCommentByIDRepo(ctx, tx, &model.GetCommentInput{
+			CommentID: *input.ParentID,
+			PostID:    post.PostID,
+		})
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "failed to get parent comment: ")
+			return nil, err
+		}
+	}
+	comment, err := model.NewComment(ctx, input, post.PostID, parent)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create comment: ")
+		return nil, err
+	}
+	if err := s.repo.CreateCommentRepo(ctx, tx, comment); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to create comment (db err): ")
+		return nil, err
+	}
+	if parent != nil {
+		if err := s.repo.AdjustReplyCountRepo(ctx, tx, parent.CommentID, 1); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.postRepo.AdjustCommentCountRepo(ctx, tx, post.PostID, 1); err != nil {
+		return nil, err
+	}
+	if err := s.notifyComment(ctx, tx, post, parent, comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+func (s *CommentService) GetCommentsService(ctx context.Context, page *model.CommentsPageReq) (*model.CommentsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	_, err = s.postRepo.GetPostByIDRepo(ctx, tx, &model.GetPostInput{
+		PostID: page.PostID,
+		Owner:  page.PostOwner,
+	})
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get post: ")
+		return nil, err
+	}
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetCommentsRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get comments page: ")
+		return nil, err
+	}
+	return res, nil
+}
+func (s *CommentService) UpdateCommentService(ctx context.Context, input *model.UpdateCommentInput, getComment *model.GetCommentInput) (*model.Comment, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	existing, err := s.getComment(ctx, tx, getComment)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Owner != getComment.Requester {
+		return nil, model.ErrCommentForbidden
+	}
+	existing.Content = input.Content
+	existing.UpdatedAt = time.Now()
+	res, err := s.repo.UpdateCommentRepo(ctx, tx, existing)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update comment: ")
+		return nil, err
+	}
+	return res, nil
+}
+
+// DeleteCommentService lets either the comment's author or the owner of the
+// post it belongs to remove it.
+func (s *CommentService) DeleteCommentService(ctx context.Context, getComment *model.GetCommentInput) (err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	existing, err := s.getComment(ctx, tx, getComment)
+	if err != nil {
+		return err
+	}
+	if existing.Owner != getComment.Requester && getComment.PostOwner != getComment.Requester {
+		return model.ErrCommentForbidden
+	}
+	if err := s.repo.DeleteCommentRepo(ctx, tx, getComment); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete comment: ")
+		return err
+	}
+	if existing.ParentID != nil {
+		if err := s.repo.AdjustReplyCountRepo(ctx, tx, *existing.ParentID, -1); err != nil {
+			return err
+		}
+	}
+	if err := s.postRepo.AdjustCommentCountRepo(ctx, tx, existing.PostID, -1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// getComment loads a comment after checking that its post is still visible
+// under the username in the URL.
+func (s *CommentService) getComment(ctx context.Context, tx pgx.Tx, getComment *model.GetCommentInput) (*model.Comment, error) {
+	_, err := s.postRepo.GetPostByIDRepo(ctx, tx, &model.GetPostInput{
+		PostID: getComment.PostID,
+		Owner:  getComment.PostOwner,
+	})
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get post: ")
+		return nil, err
+	}
+	comment, err := s.repo.GetCommentByIDRepo(ctx, tx, getComment)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get comment: ")
+		return nil, err
+	}
+	return comment, nil
+}
+
+// notifyComment tells the post owner about a new comment, and the parent's
+// author about a reply. Nobody is notified about their own comment, or twice
+// about the same one.
+func (s *CommentService) notifyComment(ctx context.Context, tx pgx.Tx, post *model.Post, parent, comment *model.Comment) error {
+	payload := model.CommentPayload{
+		PostID:    post.PostID,
+		PostOwner: post.Owner,
+		CommentID: comment.CommentID,
+		ParentID:  comment.ParentID,
+		Commenter: comment.Owner,
+	}
+	notified := map[uuid.UUID]bool{comment.UserID: true}
+	recipients := []uuid.UUID{post.UserID}
+	if parent != nil {
+		recipients = append([]uuid.UUID{parent.UserID}, recipients...)
+	}
+	for _, userID := range recipients {
+		if notified[userID] {
+			continue
+		}
+		notified[userID] = true
+		if err := s.notifier.NotifyTx(ctx, tx, userID, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
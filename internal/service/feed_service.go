@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxFeedLimit = 50
+
+type FeedServiceImpl interface {
+	GetFeedService(ctx context.Context, req *model.FeedReq) (*model.FeedRes, error)
+}
+type FeedService struct {
+	postRepo repository.PostRepoImpl
+	itemRepo repository.ItemRepoImpl
+	db       *pgxpool.Pool
+}
+
+func NewFeedService(postRepo repository.PostRepoImpl, itemRepo repository.ItemRepoImpl, db *pgxpool.Pool) FeedServiceImpl {
+	return &FeedService{
+		postRepo: postRepo,
+		itemRepo: itemRepo,
+		db:       db,
+	}
+}
+
+// GetFeedService merges the newest posts and item listings of followed users.
+// Both sides are read one entry past the limit so a next cursor is only
+// handed out when more entries really exist.
+func (s *FeedService) GetFeedService(ctx context.Context, req *model.FeedReq) (*model.FeedRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+	if req.Limit > maxFeedLimit {
+		req.Limit = maxFeedLimit
+	}
+	limit := req.Limit
+	fetch := *req
+	fetch.Limit = limit + 1
+
+	posts, err := s.postRepo.GetFeedPostsRepo(ctx, tx, &fetch)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get feed posts: ")
+		return nil, err
+	}
+	feedPosts := make([]*model.Post, len(posts))
+	for i := range posts {
+		feedPosts[i] = &posts[i]
+	}
+	if err := loadPostItems(ctx, tx, s.postRepo, feedPosts...); err != nil {
+		return nil, err
+	}
+	items, err := s.itemRepo.GetFeedItemsRepo(ctx, tx, &fetch)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get feed items: ")
+		return nil, err
+	}
+
+	entries := make([]model.FeedEntry, 0, limit+1)
+	for len(entries) <= limit && (len(posts) > 0 || len(items) > 0) {
+		var next model.FeedEntry
+		var post, item *model.FeedEntry
+		if len(posts) > 0 {
+			post = &model.FeedEntry{Type: model.FeedEntryPost, CreatedAt: posts[0].CreatedAt, Post: &posts[0]}
+		}
+		if len(items) > 0 {
+			item = &model.FeedEntry{Type: model.FeedEntryItem, CreatedAt: items[0].CreatedAt, Item: &items[0]}
+		}
+		if item == nil || (post != nil && post.Before(item)) {
+			next = *post
+			posts = posts[1:]
+		} else {
+			next = *item
+			items = items[1:]
+		}
+		entries = append(entries, next)
+	}
+
+	res := &model.FeedRes{Entries: entries}
+	if len(entries) > limit {
+		res.Entries = entries[:limit]
+		last := res.Entries[limit-1]
+		cursor := model.FeedCursor{CreatedAt: last.CreatedAt, ID: last.ID()}
+		res.NextCursor = cursor.Encode()
+	}
+	return res, nil
+}
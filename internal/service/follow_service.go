@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type FollowServiceImpl interface {
+	FollowService(ctx context.Context, username string) error
+	UnfollowService(ctx context.Context, username string) error
+	GetFollowersService(ctx context.Context, page *model.FollowsPageReq) (*model.FollowsPageRes, error)
+	GetFollowingService(ctx context.Context, page *model.FollowsPageReq) (*model.FollowsPageRes, error)
+}
+type FollowService struct {
+	repo     repository.FollowRepoImpl
+	notifier Notifier
+	db       *pgxpool.Pool
+}
+
+func NewFollowService(repo repository.FollowRepoImpl, notifier Notifier, db *pgxpool.Pool) FollowServiceImpl {
+	return &FollowService{
+		repo:     repo,
+		notifier: notifier,
+		db:       db,
+	}
+}
+
+func (s *FollowService) FollowService(ctx context.Context, username string) (err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	followeeID, err := s.repo.GetUserIDRepo(ctx, tx, username)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get user: ")
+		return err
+	}
+	follow, err := model.NewFollow(ctx, followeeID)
+	if err != nil {
+		return err
+	}
+	created, err := s.repo.FollowRepo(ctx, tx, follow)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return nil
+	}
+	if err := s.repo.AdjustFollowCountsRepo(ctx, tx, follow.FollowerID, follow.FolloweeID, 1); err != nil {
+		return err
+	}
+	return s.notifier.NotifyTx(ctx, tx, follow.FolloweeID, model.FollowPayload{
+		FollowerID: follow.FollowerID,
+		Follower:   follow.Follower,
+	})
+}
+func (s *FollowService) UnfollowService(ctx context.Context, username string) (err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	followeeID, err := s.repo.GetUserIDRepo(ctx, tx, username)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get user: ")
+		return err
+	}
+	follow, err := model.NewFollow(ctx, followeeID)
+	if err != nil {
+		return err
+	}
+	removed, err := s.repo.UnfollowRepo(ctx, tx, follow.FollowerID, follow.FolloweeID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return nil
+	}
+	return s.repo.AdjustFollowCountsRepo(ctx, tx, follow.FollowerID, follow.FolloweeID, -1)
+}
+func (s *FollowService) GetFollowersService(ctx context.Context, page *model.FollowsPageReq) (*model.FollowsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if err := normalizeFollowsPage(page); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetFollowersRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get followers: ")
+		return nil, err
+	}
+	return res, nil
+}
+func (s *FollowService) GetFollowingService(ctx context.Context, page *model.FollowsPageReq) (*model.FollowsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if err := normalizeFollowsPage(page); err != nil {
+		return nil, err
+	}
+	res, err := s.repo.GetFollowingRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get following: ")
+		return nil, err
+	}
+	return res, nil
+}
+func normalizeFollowsPage(page *model.FollowsPageReq) error {
+	if page.Username == "" {
+		return model.Invalid("username is required")
+	}
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	return nil
+}
@@ -6,71 +6,83 @@ import (
 	"time"
 
 	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/metrics"
 	"github.com/bagasadiii/buy-n-con/internal/model"
 	"github.com/bagasadiii/buy-n-con/internal/repository"
-	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ItemServiceImpl interface {
-	CreateItemService(ctx context.Context, new *model.CreateItemInput)(*model.Item, error)
-	GetItemByIDService(ctx context.Context, input *model.GetItemInput)(*model.ItemResp, error)
-	GetAllItemsService(ctx context.Context, page *model.ItemsPageReq)(*model.ItemsPageRes, error)
-	UpdateItemService(ctx context.Context, new *model.UpdateItemInput, getItem *model.GetItemInput)(*model.ItemResp, error)
-	DeleteItemService(ctx context.Context, id *uuid.UUID)error
+	CreateItemService(ctx context.Context, new *model.CreateItemInput) (*model.Item, error)
+	GetItemByIDService(ctx context.Context, input *model.GetItemInput) (*model.ItemResp, error)
+	GetAllItemsService(ctx context.Context, page *model.ItemsPageReq) (*model.ItemsPageRes, error)
+	UpdateItemService(ctx context.Context, new *model.UpdateItemInput, getItem *model.GetItemInput) (*model.ItemResp, error)
+	DeleteItemService(ctx context.Context, input *model.GetItemInput) error
+	GetDeletedItemsService(ctx context.Context, page *model.ItemsPageReq) (*model.ItemsPageRes, error)
+	RestoreItemService(ctx context.Context, input *model.GetItemInput) (*model.ItemResp, error)
+	PurgeDeletedItemsService(ctx context.Context, before time.Time) (int64, error)
+	GetItemHistoryService(ctx context.Context, getItem *model.GetItemInput, page *model.ItemHistoryReq) (*model.ItemHistoryRes, error)
+	UpdateItemStatusService(ctx context.Context, input *model.UpdateItemStatusInput, getItem *model.GetItemInput) (*model.ItemResp, error)
 }
 type ItemService struct {
-	repo repository.ItemRepoImpl
-	db *pgxpool.Pool
+	repo     repository.ItemRepoImpl
+	notifier Notifier
+	webhooks WebhookDispatcher
+	db       *pgxpool.Pool
 }
-func NewItemService(repo repository.ItemRepoImpl, db *pgxpool.Pool)ItemServiceImpl{
+
+func NewItemService(repo repository.ItemRepoImpl, notifier Notifier, webhooks WebhookDispatcher, db *pgxpool.Pool) ItemServiceImpl {
 	return &ItemService{
-		repo:repo,
-		db:db,
+		repo:     repo,
+		notifier: notifier,
+		webhooks: webhooks,
+		db:       db,
 	}
 }
-func(s *ItemService)CreateItemService(ctx context.Context, new *model.CreateItemInput)(*model.Item, error){
+func (s *ItemService) CreateItemService(ctx context.Context, new *model.CreateItemInput) (*model.Item, error) {
 	item, err := model.NewItem(ctx, new)
 	if err != nil {
-		helper.ErrMsg(err, "failed to create item: ")
+		helper.ErrMsgCtx(ctx, err, "failed to create item: ")
 		return nil, err
 	}
-	tx, err := s.db.Begin(ctx)
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transaction: ")
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
 		return nil, err
 	}
 	defer helper.CommitOrRollback(ctx, tx)
 	if err := s.repo.CreateItemRepo(ctx, tx, item); err != nil {
-		helper.ErrMsg(err, "failed to create item(db err): ")
+		helper.ErrMsgCtx(ctx, err, "failed to create item(db err): ")
 		return nil, err
 	}
+	metrics.ItemsCreated.Inc()
 	return item, nil
 }
-func(s *ItemService)GetItemByIDService(ctx context.Context, input *model.GetItemInput)(*model.ItemResp, error){
-	tx, err := s.db.Begin(ctx)
+func (s *ItemService) GetItemByIDService(ctx context.Context, input *model.GetItemInput) (*model.ItemResp, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get item(tx error): ")
+		helper.ErrMsgCtx(ctx, err, "failed to get item(tx error): ")
 		return nil, err
 	}
 	defer helper.CommitOrRollback(ctx, tx)
 	item, err := s.repo.GetItemByIDRepo(ctx, tx, input)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get item(db error): ")
+		helper.ErrMsgCtx(ctx, err, "failed to get item(db error): ")
 		return nil, err
 	}
 	return item, nil
 }
-func(s *ItemService)GetAllItemsService(ctx context.Context, page *model.ItemsPageReq)(*model.ItemsPageRes, error){
-	tx, err := s.db.Begin(ctx)
+func (s *ItemService) GetAllItemsService(ctx context.Context, page *model.ItemsPageReq) (*model.ItemsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transaction: ")
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
 		return nil, err
 	}
 	defer helper.CommitOrRollback(ctx, tx)
 
 	if page.Username == "" {
-		return nil, errors.New("invalid username")
+		return nil, model.Invalid("username is required")
 	}
 	if page.Limit <= 0 {
 		page.Limit = 10
@@ -78,63 +90,254 @@ func(s *ItemService)GetAllItemsService(ctx context.Context, page *model.ItemsPag
 	if page.Offset < 0 {
 		page.Offset = 0
 	}
+	if page.Status == "" {
+		page.Status = model.ItemStatusActive
+	}
+	if !page.Status.Valid() {
+		return nil, model.Invalid("invalid item status")
+	}
+	if !page.Status.Public() && page.Viewer != page.Username {
+		return nil, model.Forbidden("only the owner can list draft or archived items")
+	}
 	res, err := s.repo.GetAllItemsRepo(ctx, tx, page)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get itemtransaction: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get itemtransaction: ")
 		return nil, err
 	}
 	return res, nil
 }
-func(s *ItemService)UpdateItemService(ctx context.Context, new *model.UpdateItemInput, getItem *model.GetItemInput)(*model.ItemResp, error){
-	tx, err := s.db.Begin(ctx)
+func (s *ItemService) UpdateItemService(ctx context.Context, new *model.UpdateItemInput, getItem *model.GetItemInput) (res *model.ItemResp, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	existingItem, err := s.repo.GetItemByIDRepo(ctx, tx, getItem)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transaction: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get item: ")
+		return nil, err
+	}
+	if err := model.CheckETag(getItem.IfMatch, helper.ETag(existingItem.Version, existingItem)); err != nil {
 		return nil, err
 	}
+	merged := *existingItem
+	if err := new.Apply(&merged); err != nil {
+		return nil, err
+	}
+	merged.UpdatedAt = time.Now()
+	res, err = s.repo.ItemUpdateRepo(ctx, tx, &merged, existingItem.Version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to while update item: ")
+		return nil, model.VersionError(getItem.IfMatch, err)
+	}
+	if err := s.recordRevisions(ctx, tx, existingItem, res); err != nil {
+		return nil, err
+	}
+	if err := s.notifyPriceDrop(ctx, tx, existingItem, res); err != nil {
+		return nil, err
+	}
+	if err := s.dispatchSoldOut(ctx, tx, existingItem, res); err != nil {
+		return nil, err
+	}
+	return s.getUpdatedItem(ctx, tx, getItem)
+}
+func (s *ItemService) DeleteItemService(ctx context.Context, input *model.GetItemInput) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
 	defer helper.CommitOrRollback(ctx, tx)
+	existingItem, err := s.repo.GetItemByIDRepo(ctx, tx, input)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get item: ")
+		return err
+	}
+	if err := model.CheckETag(input.IfMatch, helper.ETag(existingItem.Version, existingItem)); err != nil {
+		return err
+	}
+	err = s.repo.ItemDeleteRepo(ctx, tx, input, existingItem.Version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete item: ")
+		return model.VersionError(input.IfMatch, err)
+	}
+	return nil
+}
+func (s *ItemService) UpdateItemStatusService(ctx context.Context, input *model.UpdateItemStatusInput, getItem *model.GetItemInput) (res *model.ItemResp, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
 	existingItem, err := s.repo.GetItemByIDRepo(ctx, tx, getItem)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get item: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get item: ")
+		return nil, err
+	}
+	if err := model.CheckETag(getItem.IfMatch, helper.ETag(existingItem.Version, existingItem)); err != nil {
+		return nil, err
+	}
+	if !existingItem.Status.CanTransitionTo(input.Status) {
+		return nil, model.ErrInvalidStatusTransition
+	}
+	if input.Status == model.ItemStatusActive && existingItem.Quantity == 0 {
+		return nil, model.ErrInvalidStatusTransition
+	}
+	res, err = s.repo.UpdateItemStatusRepo(ctx, tx, input.Status, getItem.ItemID, existingItem.Version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to update item status: ")
+		return nil, model.VersionError(getItem.IfMatch, err)
+	}
+	if err := s.recordRevisions(ctx, tx, existingItem, res); err != nil {
 		return nil, err
 	}
-	if new.Name == "" {
-		new.Name = existingItem.Name
+	if err := s.dispatchSoldOut(ctx, tx, existingItem, res); err != nil {
+		return nil, err
 	}
-	if new.Price == 0 {
-		new.Price = existingItem.Price
+	return s.getUpdatedItem(ctx, tx, getItem)
+}
+func (s *ItemService) GetDeletedItemsService(ctx context.Context, page *model.ItemsPageReq) (*model.ItemsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
 	}
-	if new.Quantity == 0 {
-		new.Quantity = existingItem.Quantity
+	defer helper.CommitOrRollback(ctx, tx)
+
+	if page.Username == "" {
+		return nil, model.Invalid("username is required")
 	}
-	if new.Description == ""{
-		new.Description = existingItem.Description
+	if page.Limit <= 0 {
+		page.Limit = 10
 	}
-	updateItem := model.UpdateItemInput{
-		Name: new.Name,
-		Quantity: new.Quantity,
-		Price: new.Price,
-		Description: new.Description,
-		UpdatedAt: time.Now(),
+	if page.Offset < 0 {
+		page.Offset = 0
 	}
-	id := getItem.ItemID
-	res, err := s.repo.ItemUpdateRepo(ctx, tx, &updateItem, id)
+	res, err := s.repo.GetDeletedItemsRepo(ctx, tx, page)
 	if err != nil {
-		helper.ErrMsg(err, "failed to while update item: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get deleted items: ")
 		return nil, err
 	}
 	return res, nil
 }
-func(s *ItemService)DeleteItemService(ctx context.Context, id *uuid.UUID)error{
-    tx, err := s.db.Begin(ctx)
-    if err != nil {
-        helper.ErrMsg(err, "failed to begin transaction: ")
-        return err
-    }
-    defer helper.CommitOrRollback(ctx, tx)
-    err = s.repo.ItemDeleteRepo(ctx, tx, id)
-    if err != nil {
-        helper.ErrMsg(err, "failed to delete item: ")
-        return err
-    }
-    return nil
-}
\ No newline at end of file
+func (s *ItemService) RestoreItemService(ctx context.Context, input *model.GetItemInput) (*model.ItemResp, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	item, err := s.repo.RestoreItemRepo(ctx, tx, input)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to restore item: ")
+		return nil, err
+	}
+	return item, nil
+}
+func (s *ItemService) PurgeDeletedItemsService(ctx context.Context, before time.Time) (int64, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return 0, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	purged, err := s.repo.PurgeDeletedItemsRepo(ctx, tx, before)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to purge deleted items: ")
+		return 0, err
+	}
+	return purged, nil
+}
+func (s *ItemService) GetItemHistoryService(ctx context.Context, getItem *model.GetItemInput, page *model.ItemHistoryReq) (*model.ItemHistoryRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if _, err := s.repo.GetItemByIDRepo(ctx, tx, getItem); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get item: ")
+		return nil, err
+	}
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	page.ItemID = getItem.ItemID
+	res, err := s.repo.GetItemHistoryRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get item history: ")
+		return nil, err
+	}
+	return res, nil
+}
+func (s *ItemService) recordRevisions(ctx context.Context, tx pgx.Tx, before, after *model.ItemResp) error {
+	revisions, err := model.NewItemRevisions(ctx, before, after)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to build item revisions: ")
+		return err
+	}
+	if err := s.repo.CreateItemRevisionsRepo(ctx, tx, revisions); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to record item revisions: ")
+		return err
+	}
+	return nil
+}
+
+// notifyPriceDrop tells everyone watching a public item that it got cheaper.
+func (s *ItemService) notifyPriceDrop(ctx context.Context, tx pgx.Tx, before, after *model.ItemResp) error {
+	if after.Price >= before.Price || !after.Status.Public() {
+		return nil
+	}
+	watchers, err := s.repo.GetItemWatchersRepo(ctx, tx, after.ItemID)
+	if err != nil {
+		return err
+	}
+	payload := model.PriceDropPayload{
+		ItemID:   after.ItemID,
+		Owner:    after.Owner,
+		Name:     after.Name,
+		OldPrice: before.Price,
+		NewPrice: after.Price,
+	}
+	for _, userID := range watchers {
+		if err := s.notifier.NotifyTx(ctx, tx, userID, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getUpdatedItem reads an item back after a write the way GET shows it, so
+// the response and its ETag include the viewer's own reaction, which
+// UPDATE ... RETURNING does not.
+func (s *ItemService) getUpdatedItem(ctx context.Context, tx pgx.Tx, getItem *model.GetItemInput) (*model.ItemResp, error) {
+	item, err := s.repo.GetItemByIDRepo(ctx, tx, getItem)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get updated item: ")
+		return nil, err
+	}
+	return item, nil
+}
+
+// dispatchSoldOut fires item.sold_out to the owner's webhooks when an item
+// is marked sold or its last unit is gone. An owner whose account is gone
+// has no webhooks left.
+func (s *ItemService) dispatchSoldOut(ctx context.Context, tx pgx.Tx, before, after *model.ItemResp) error {
+	soldOut := after.Status == model.ItemStatusSold || after.Quantity == 0
+	if !soldOut || !after.Status.Public() || before.Status == model.ItemStatusSold || before.Quantity == 0 {
+		return nil
+	}
+	ownerID, err := s.repo.GetUserIDRepo(ctx, tx, after.Owner)
+	if errors.Is(err, model.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.webhooks.DispatchTx(ctx, tx, ownerID, model.WebhookItemSoldOut, after)
+}
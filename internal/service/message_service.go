@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MessageServiceImpl interface {
+	StartConversationService(ctx context.Context, userID uuid.UUID, input *model.ConversationInput) (*model.Conversation, error)
+	GetConversationsService(ctx context.Context, page *model.ConversationsPageReq) (*model.ConversationsPageRes, error)
+	GetMessagesService(ctx context.Context, page *model.MessagesPageReq) (*model.MessagesPageRes, error)
+	SendMessageService(ctx context.Context, userID, conversationID uuid.UUID, input *model.MessageInput) (*model.Message, error)
+	MarkReadService(ctx context.Context, userID, conversationID uuid.UUID) error
+	BlockUserService(ctx context.Context, userID uuid.UUID, username string) error
+	UnblockUserService(ctx context.Context, userID uuid.UUID, username string) error
+}
+type MessageService struct {
+	repo      repository.MessageRepoImpl
+	publisher realtime.Publisher
+	db        *pgxpool.Pool
+}
+
+func NewMessageService(repo repository.MessageRepoImpl, publisher realtime.Publisher, db *pgxpool.Pool) MessageServiceImpl {
+	return &MessageService{
+		repo:      repo,
+		publisher: publisher,
+		db:        db,
+	}
+}
+
+// StartConversationService opens the conversation with input.Username, or
+// returns the existing one. An item anchor must belong to one of the two
+// users, which is the usual buyer asking a seller case.
+func (s *MessageService) StartConversationService(ctx context.Context, userID uuid.UUID, input *model.ConversationInput) (res *model.Conversation, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	peerID, err := s.repo.GetUserIDRepo(ctx, tx, input.Username)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get user: ")
+		return nil, err
+	}
+	if peerID == userID {
+		return nil, model.ErrMessageSelf
+	}
+	blocked, err := s.repo.IsBlockedRepo(ctx, tx, userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, model.ErrMessageBlocked
+	}
+	if input.ItemID != nil {
+		ownerID, err := s.repo.GetItemOwnerIDRepo(ctx, tx, *input.ItemID)
+		if err != nil {
+			helper.ErrMsgCtx(ctx, err, "failed to get item: ")
+			return nil, err
+		}
+		if ownerID != userID && ownerID != peerID {
+			return nil, model.ErrItemNotInConversation
+		}
+	}
+	userA, userB := model.ConversationMembers(userID, peerID)
+	conversationID, err := s.repo.GetOrCreateConversationRepo(ctx, tx, userA, userB, input.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetConversationRepo(ctx, tx, conversationID, userID)
+}
+func (s *MessageService) GetConversationsService(ctx context.Context, page *model.ConversationsPageReq) (*model.ConversationsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetConversationsRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get conversations: ")
+		return nil, err
+	}
+	return res, nil
+}
+func (s *MessageService) GetMessagesService(ctx context.Context, page *model.MessagesPageReq) (*model.MessagesPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if _, err := s.repo.GetConversationRepo(ctx, tx, page.ConversationID, page.UserID); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get conversation: ")
+		return nil, err
+	}
+	if page.Limit <= 0 {
+		page.Limit = 50
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetMessagesRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get messages: ")
+		return nil, err
+	}
+	return res, nil
+}
+func (s *MessageService) SendMessageService(ctx context.Context, userID, conversationID uuid.UUID, input *model.MessageInput) (res *model.Message, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	conversation, err := s.repo.GetConversationRepo(ctx, tx, conversationID, userID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get conversation: ")
+		return nil, err
+	}
+	blocked, err := s.repo.IsBlockedRepo(ctx, tx, userID, conversation.Peer.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, model.ErrMessageBlocked
+	}
+	message, err := model.NewMessage(ctx, input, conversation.ConversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateMessageRepo(ctx, tx, message); err != nil {
+		return nil, err
+	}
+	event, err := realtime.NewEvent(realtime.EventMessageCreated, message)
+	if err != nil {
+		return nil, err
+	}
+	// The sender gets the event too, so their other open sessions stay in sync.
+	for _, recipient := range []uuid.UUID{conversation.Peer.UserID, userID} {
+		if err := s.publisher.Publish(ctx, tx, recipient, event); err != nil {
+			return nil, err
+		}
+	}
+	return message, nil
+}
+func (s *MessageService) MarkReadService(ctx context.Context, userID, conversationID uuid.UUID) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if _, err := s.repo.GetConversationRepo(ctx, tx, conversationID, userID); err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get conversation: ")
+		return err
+	}
+	// Read receipts compare last_read_at with created_at, which comes from
+	// the same clock.
+	return s.repo.MarkConversationReadRepo(ctx, tx, conversationID, userID, time.Now())
+}
+func (s *MessageService) BlockUserService(ctx context.Context, userID uuid.UUID, username string) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	blockedID, err := s.repo.GetUserIDRepo(ctx, tx, username)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get user: ")
+		return err
+	}
+	if blockedID == userID {
+		return model.ErrBlockSelf
+	}
+	return s.repo.BlockRepo(ctx, tx, userID, blockedID)
+}
+func (s *MessageService) UnblockUserService(ctx context.Context, userID uuid.UUID, username string) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	blockedID, err := s.repo.GetUserIDRepo(ctx, tx, username)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get user: ")
+		return err
+	}
+	return s.repo.UnblockRepo(ctx, tx, userID, blockedID)
+}
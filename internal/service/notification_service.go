@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Notifier is what other services use to notify a user. NotifyTx joins the
+// caller's transaction, so the notification only exists if the change that
+// caused it is committed.
+type Notifier interface {
+	Notify(ctx context.Context, userID uuid.UUID, event model.NotificationEvent) error
+	NotifyTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event model.NotificationEvent) error
+}
+
+type NotificationServiceImpl interface {
+	Notifier
+	GetNotificationsService(ctx context.Context, page *model.NotificationsPageReq) (*model.NotificationsPageRes, error)
+	MarkNotificationReadService(ctx context.Context, userID, notificationID uuid.UUID) error
+	MarkAllNotificationsReadService(ctx context.Context, userID uuid.UUID) (int64, error)
+	GetNotificationPreferencesService(ctx context.Context, userID uuid.UUID) (model.NotificationPreferences, error)
+	UpdateNotificationPreferencesService(ctx context.Context, userID uuid.UUID, prefs model.NotificationPreferences) (model.NotificationPreferences, error)
+}
+type NotificationService struct {
+	repo      repository.NotificationRepoImpl
+	publisher realtime.Publisher
+	db        *pgxpool.Pool
+}
+
+func NewNotificationService(repo repository.NotificationRepoImpl, publisher realtime.Publisher, db *pgxpool.Pool) NotificationServiceImpl {
+	return &NotificationService{
+		repo:      repo,
+		publisher: publisher,
+		db:        db,
+	}
+}
+
+func (s *NotificationService) Notify(ctx context.Context, userID uuid.UUID, event model.NotificationEvent) (err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	return s.NotifyTx(ctx, tx, userID, event)
+}
+
+// NotifyTx stores the notification on the channel the user picked for its
+// type and pushes it to their open connections. Nothing is stored when the
+// user turned the type off.
+func (s *NotificationService) NotifyTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event model.NotificationEvent) error {
+	channel, err := s.repo.GetNotificationChannelRepo(ctx, tx, userID, event.NotificationType())
+	if err != nil {
+		return err
+	}
+	if channel == model.ChannelOff {
+		return nil
+	}
+	notification, err := model.NewNotification(userID, event, channel)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.CreateNotificationRepo(ctx, tx, notification); err != nil {
+		return err
+	}
+	pushed, err := realtime.NewEvent(realtime.EventNotificationCreated, notification)
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, tx, userID, pushed)
+}
+func (s *NotificationService) GetNotificationsService(ctx context.Context, page *model.NotificationsPageReq) (*model.NotificationsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetNotificationsRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get notifications: ")
+		return nil, err
+	}
+	return res, nil
+}
+func (s *NotificationService) MarkNotificationReadService(ctx context.Context, userID, notificationID uuid.UUID) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	return s.repo.MarkNotificationReadRepo(ctx, tx, userID, notificationID)
+}
+func (s *NotificationService) MarkAllNotificationsReadService(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return 0, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	return s.repo.MarkAllNotificationsReadRepo(ctx, tx, userID)
+}
+func (s *NotificationService) GetNotificationPreferencesService(ctx context.Context, userID uuid.UUID) (model.NotificationPreferences, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	return s.repo.GetNotificationPreferencesRepo(ctx, tx, userID)
+}
+
+// UpdateNotificationPreferencesService changes only the types present in
+// prefs and returns the full set afterwards.
+func (s *NotificationService) UpdateNotificationPreferencesService(ctx context.Context, userID uuid.UUID, prefs model.NotificationPreferences) (res model.NotificationPreferences, err error) {
+	if err := prefs.Validate(); err != nil {
+		return nil, err
+	}
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	if err := s.repo.SetNotificationPreferencesRepo(ctx, tx, userID, prefs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetNotificationPreferencesRepo(ctx, tx, userID)
+}
@@ -2,78 +2,101 @@ package service
 
 import (
 	"context"
-	"errors"
 	"time"
 
 	"github.com/bagasadiii/buy-n-con/helper"
 	"github.com/bagasadiii/buy-n-con/internal/model"
 	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PostServiceImpl interface {
-	CreatePostService(ctx context.Context, input *model.PostInput)(*model.Post, error)
-	GetPostByIDService(ctx context.Context, input *model.GetPostInput)(*model.Post, error)
-	GetAllPostService(ctx context.Context, page *model.PostsPageReq)(*model.PostsPageRes, error)
-	UpdatePostService(ctx context.Context, new *model.UpdatePostInput, getPost *model.GetPostInput)(*model.Post, error)
-	DeletePostService(ctx context.Context, getPost *model.GetPostInput)error
+	CreatePostService(ctx context.Context, input *model.PostInput) (*model.Post, error)
+	GetPostByIDService(ctx context.Context, input *model.GetPostInput) (*model.Post, error)
+	GetAllPostService(ctx context.Context, page *model.PostsPageReq) (*model.PostsPageRes, error)
+	UpdatePostService(ctx context.Context, new *model.UpdatePostInput, getPost *model.GetPostInput) (*model.Post, error)
+	DeletePostService(ctx context.Context, getPost *model.GetPostInput) error
+	GetDeletedPostsService(ctx context.Context, page *model.PostsPageReq) (*model.PostsPageRes, error)
+	RestorePostService(ctx context.Context, getPost *model.GetPostInput) (*model.Post, error)
+	PurgeDeletedPostsService(ctx context.Context, before time.Time) (int64, error)
 }
 
 type PostService struct {
-	repo repository.PostRepoImpl
-	db *pgxpool.Pool
+	repo     repository.PostRepoImpl
+	tagRepo  repository.TagRepoImpl
+	notifier Notifier
+	db       *pgxpool.Pool
 }
 
-func NewServiceImpl(repo repository.PostRepoImpl, db *pgxpool.Pool)PostServiceImpl{
+func NewServiceImpl(repo repository.PostRepoImpl, tagRepo repository.TagRepoImpl, notifier Notifier, db *pgxpool.Pool) PostServiceImpl {
 	return &PostService{
-		repo:repo,
-		db:db,
+		repo:     repo,
+		tagRepo:  tagRepo,
+		notifier: notifier,
+		db:       db,
 	}
 }
 
-func(s *PostService)CreatePostService(ctx context.Context, input *model.PostInput)(*model.Post, error){
+func (s *PostService) CreatePostService(ctx context.Context, input *model.PostInput) (res *model.Post, err error) {
 	post, err := model.NewPost(ctx, input)
 	if err != nil {
-		helper.ErrMsg(err, "failed to create posts")
+		helper.ErrMsgCtx(ctx, err, "failed to create posts")
 		return nil, err
 	}
-	tx, err := s.db.Begin(ctx)
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transaction")
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	if err := s.repo.CheckPostItemsRepo(ctx, tx, post); err != nil {
 		return nil, err
 	}
-	defer helper.CommitOrRollback(ctx, tx)
 	if err := s.repo.CreatePostRepo(ctx, tx, post); err != nil {
-		helper.ErrMsg(err, "failed to create post (db err)")
+		helper.ErrMsgCtx(ctx, err, "failed to create post (db err)")
+		return nil, err
+	}
+	if err := s.repo.SetPostItemsRepo(ctx, tx, post); err != nil {
+		return nil, err
+	}
+	if err := s.syncTags(ctx, tx, post); err != nil {
+		return nil, err
+	}
+	if err := loadPostItems(ctx, tx, s.repo, post); err != nil {
 		return nil, err
 	}
 	return post, nil
 }
-func(s *PostService)GetPostByIDService(ctx context.Context, input *model.GetPostInput)(*model.Post, error){
-	tx, err := s.db.Begin(ctx)
+func (s *PostService) GetPostByIDService(ctx context.Context, input *model.GetPostInput) (*model.Post, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transactions")
+		helper.ErrMsgCtx(ctx, err, "failed to begin transactions")
 		return nil, err
 	}
 	defer helper.CommitOrRollback(ctx, tx)
 
-	post, err := s.repo.GetPostByIDRepo(ctx,tx, input)
+	post, err := s.repo.GetPostByIDRepo(ctx, tx, input)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get post")
+		helper.ErrMsgCtx(ctx, err, "failed to get post")
+		return nil, err
+	}
+	if err := loadPostItems(ctx, tx, s.repo, post); err != nil {
 		return nil, err
 	}
 	return post, nil
 }
-func(s *PostService)GetAllPostService(ctx context.Context, page *model.PostsPageReq)(*model.PostsPageRes, error){
-	tx, err := s.db.Begin(ctx)
+func (s *PostService) GetAllPostService(ctx context.Context, page *model.PostsPageReq) (*model.PostsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transactions")
+		helper.ErrMsgCtx(ctx, err, "failed to begin transactions")
 		return nil, err
 	}
 	defer helper.CommitOrRollback(ctx, tx)
 
 	if page.Username == "" {
-		return nil, errors.New("invalid username")
+		return nil, model.Invalid("username is required")
 	}
 	if page.Limit <= 0 {
 		page.Limit = 10
@@ -83,45 +106,191 @@ func(s *PostService)GetAllPostService(ctx context.Context, page *model.PostsPage
 	}
 	res, err := s.repo.GetAllPostRepo(ctx, tx, page)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get posts page")
+		helper.ErrMsgCtx(ctx, err, "failed to get posts page")
+		return nil, err
+	}
+	posts := make([]*model.Post, len(res.Posts))
+	for i := range res.Posts {
+		posts[i] = &res.Posts[i]
+	}
+	if err := loadPostItems(ctx, tx, s.repo, posts...); err != nil {
 		return nil, err
 	}
 	return res, nil
 }
-func(s *PostService)UpdatePostService(ctx context.Context, new *model.UpdatePostInput, getPost *model.GetPostInput)(*model.Post, error){
-	tx, err := s.db.Begin(ctx)
+func (s *PostService) UpdatePostService(ctx context.Context, new *model.UpdatePostInput, getPost *model.GetPostInput) (res *model.Post, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
 	if err != nil {
-		helper.ErrMsg(err, "failed to begin transaction: ")
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	existingPost, err := s.repo.GetPostByIDRepo(ctx, tx, getPost)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get post: ")
+		return nil, err
+	}
+	if err := loadPostItems(ctx, tx, s.repo, existingPost); err != nil {
+		return nil, err
+	}
+	if err := model.CheckETag(getPost.IfMatch, helper.ETag(existingPost.Version, existingPost)); err != nil {
 		return nil, err
 	}
+	merged := *existingPost
+	if err := new.Apply(&merged); err != nil {
+		return nil, err
+	}
+	if merged.ItemIDs != nil {
+		if err := s.repo.CheckPostItemsRepo(ctx, tx, &merged); err != nil {
+			return nil, err
+		}
+	}
+	merged.UpdatedAt = time.Now()
+	res, err = s.repo.UpdatePostRepo(ctx, tx, &merged, existingPost.Version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to while update post: ")
+		return nil, model.VersionError(getPost.IfMatch, err)
+	}
+	if merged.ItemIDs != nil {
+		if err := s.repo.SetPostItemsRepo(ctx, tx, &merged); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.syncTags(ctx, tx, res); err != nil {
+		return nil, err
+	}
+	// Read the post back the way GET shows it, so the response and its ETag
+	// include the viewer's own reaction, which UPDATE ... RETURNING does not.
+	res, err = s.repo.GetPostByIDRepo(ctx, tx, getPost)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get updated post: ")
+		return nil, err
+	}
+	if err := loadPostItems(ctx, tx, s.repo, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+func (s *PostService) DeletePostService(ctx context.Context, getPost *model.GetPostInput) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
 	defer helper.CommitOrRollback(ctx, tx)
 	existingPost, err := s.repo.GetPostByIDRepo(ctx, tx, getPost)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get post: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get post: ")
+		return err
+	}
+	if err := loadPostItems(ctx, tx, s.repo, existingPost); err != nil {
+		return err
+	}
+	if err := model.CheckETag(getPost.IfMatch, helper.ETag(existingPost.Version, existingPost)); err != nil {
+		return err
+	}
+	err = s.repo.DeletePostRepo(ctx, tx, getPost, existingPost.Version)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to delete post: ")
+		return model.VersionError(getPost.IfMatch, err)
+	}
+	return nil
+}
+func (s *PostService) GetDeletedPostsService(ctx context.Context, page *model.PostsPageReq) (*model.PostsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transactions")
 		return nil, err
 	}
-	if new.Content == "" {
-		new.Content = existingPost.Content
+	defer helper.CommitOrRollback(ctx, tx)
+
+	if page.Username == "" {
+		return nil, model.Invalid("username is required")
 	}
-	new.UpdatedAt = time.Now()
-	res, err := s.repo.UpdatePostRepo(ctx, tx, new)
+	if page.Limit <= 0 {
+		page.Limit = 10
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetDeletedPostsRepo(ctx, tx, page)
 	if err != nil {
-		helper.ErrMsg(err, "failed to while update post: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get deleted posts page")
 		return nil, err
 	}
 	return res, nil
 }
-func(s *PostService)DeletePostService(ctx context.Context, getPost *model.GetPostInput)error{
-    tx, err := s.db.Begin(ctx)
-    if err != nil {
-        helper.ErrMsg(err, "failed to begin transaction: ")
-        return err
-    }
-    defer helper.CommitOrRollback(ctx, tx)
-    err = s.repo.DeletePostRepo(ctx, tx ,getPost)
-    if err != nil {
-        helper.ErrMsg(err, "failed to delete post: ")
-        return err
-    }
-    return nil
-}
\ No newline at end of file
+func (s *PostService) RestorePostService(ctx context.Context, getPost *model.GetPostInput) (*model.Post, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	post, err := s.repo.RestorePostRepo(ctx, tx, getPost)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to restore post: ")
+		return nil, err
+	}
+	if err := loadPostItems(ctx, tx, s.repo, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+func (s *PostService) PurgeDeletedPostsService(ctx context.Context, before time.Time) (int64, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return 0, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	purged, err := s.repo.PurgeDeletedPostsRepo(ctx, tx, before)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to purge deleted posts: ")
+		return 0, err
+	}
+	return purged, nil
+}
+
+// syncTags indexes the hashtags and mentions in the post's content and
+// notifies users the first time a post mentions them.
+func (s *PostService) syncTags(ctx context.Context, tx pgx.Tx, post *model.Post) error {
+	tags, mentions := model.ParseTags(post.Content)
+	if err := s.tagRepo.SetPostTagsRepo(ctx, tx, post.PostID, tags); err != nil {
+		return err
+	}
+	mentioned, err := s.tagRepo.SetPostMentionsRepo(ctx, tx, post, mentions)
+	if err != nil {
+		return err
+	}
+	for _, userID := range mentioned {
+		err := s.notifier.NotifyTx(ctx, tx, userID, model.MentionPayload{
+			PostID:    post.PostID,
+			PostOwner: post.Owner,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadPostItems fills in the item cards of the given posts with one query.
+func loadPostItems(ctx context.Context, tx pgx.Tx, repo repository.PostRepoImpl, posts ...*model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(posts))
+	for i, post := range posts {
+		ids[i] = post.PostID
+	}
+	cards, err := repo.GetPostItemsRepo(ctx, tx, ids)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get post items: ")
+		return err
+	}
+	for _, post := range posts {
+		post.Items = cards[post.PostID]
+	}
+	return nil
+}
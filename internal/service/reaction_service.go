@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReactionServiceImpl interface {
+	ReactToPostService(ctx context.Context, input *model.ReactionInput, getPost *model.GetPostInput) (*model.ReactionResp, error)
+	ReactToItemService(ctx context.Context, input *model.ReactionInput, getItem *model.GetItemInput) (*model.ReactionResp, error)
+}
+type ReactionService struct {
+	repo     repository.ReactionRepoImpl
+	postRepo repository.PostRepoImpl
+	itemRepo repository.ItemRepoImpl
+	db       *pgxpool.Pool
+}
+
+func NewReactionService(repo repository.ReactionRepoImpl, postRepo repository.PostRepoImpl, itemRepo repository.ItemRepoImpl, db *pgxpool.Pool) ReactionServiceImpl {
+	return &ReactionService{
+		repo:     repo,
+		postRepo: postRepo,
+		itemRepo: itemRepo,
+		db:       db,
+	}
+}
+
+func (s *ReactionService) ReactToPostService(ctx context.Context, input *model.ReactionInput, getPost *model.GetPostInput) (res *model.ReactionResp, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	post, err := s.postRepo.GetPostByIDRepo(ctx, tx, getPost)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get post: ")
+		return nil, err
+	}
+	return s.toggle(ctx, tx, model.ReactionTargetPost, post.PostID, input.Reaction)
+}
+func (s *ReactionService) ReactToItemService(ctx context.Context, input *model.ReactionInput, getItem *model.GetItemInput) (res *model.ReactionResp, err error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.EndTx(ctx, tx, &err)
+	item, err := s.itemRepo.GetItemByIDRepo(ctx, tx, getItem)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get item: ")
+		return nil, err
+	}
+	return s.toggle(ctx, tx, model.ReactionTargetItem, item.ItemID, input.Reaction)
+}
+
+// toggle applies a reaction with toggle semantics: sending the reaction the
+// user already left removes it, sending a different one replaces it.
+func (s *ReactionService) toggle(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID uuid.UUID, reaction model.Reaction) (*model.ReactionResp, error) {
+	record, err := model.NewReactionRecord(ctx, target, targetID, reaction)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.repo.GetReactionRepo(ctx, tx, target, targetID, record.UserID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get reaction: ")
+		return nil, err
+	}
+	res := &model.ReactionResp{TargetID: targetID}
+	if current != nil && *current == reaction {
+		if err := s.repo.DeleteReactionRepo(ctx, tx, target, targetID, record.UserID); err != nil {
+			return nil, err
+		}
+		res.ReactionCounts, err = s.repo.AdjustReactionCountRepo(ctx, tx, target, targetID, reaction, -1)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	if err := s.repo.UpsertReactionRepo(ctx, tx, record); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		if _, err := s.repo.AdjustReactionCountRepo(ctx, tx, target, targetID, *current, -1); err != nil {
+			return nil, err
+		}
+	}
+	res.ReactionCounts, err = s.repo.AdjustReactionCountRepo(ctx, tx, target, targetID, reaction, 1)
+	if err != nil {
+		return nil, err
+	}
+	res.MyReaction = &reaction
+	return res, nil
+}
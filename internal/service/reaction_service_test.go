@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeReactionRepo keeps one target's reactions and counts in memory.
+type fakeReactionRepo struct {
+	repository.ReactionRepoImpl
+	reactions map[uuid.UUID]model.Reaction
+	counts    map[model.Reaction]int
+}
+
+func (r *fakeReactionRepo) GetReactionRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID, userID uuid.UUID) (*model.Reaction, error) {
+	reaction, ok := r.reactions[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &reaction, nil
+}
+
+func (r *fakeReactionRepo) UpsertReactionRepo(ctx context.Context, tx pgx.Tx, reaction *model.ReactionRecord) error {
+	r.reactions[reaction.UserID] = reaction.Reaction
+	return nil
+}
+
+func (r *fakeReactionRepo) DeleteReactionRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID, userID uuid.UUID) error {
+	delete(r.reactions, userID)
+	return nil
+}
+
+func (r *fakeReactionRepo) AdjustReactionCountRepo(ctx context.Context, tx pgx.Tx, target model.ReactionTarget, targetID uuid.UUID, reaction model.Reaction, delta int) (map[model.Reaction]int, error) {
+	r.counts[reaction] += delta
+	if r.counts[reaction] == 0 {
+		delete(r.counts, reaction)
+	}
+	counts := map[model.Reaction]int{}
+	for k, v := range r.counts {
+		counts[k] = v
+	}
+	return counts, nil
+}
+
+func TestReactionToggle(t *testing.T) {
+	repo := &fakeReactionRepo{reactions: map[uuid.UUID]model.Reaction{}, counts: map[model.Reaction]int{}}
+	s := &ReactionService{repo: repo}
+	targetID := uuid.New()
+	alice := context.WithValue(context.Background(), middleware.UserContextKey, &middleware.ContextKey{UserIDKey: uuid.New(), UsernameKey: "alice"})
+	bob := context.WithValue(context.Background(), middleware.UserContextKey, &middleware.ContextKey{UserIDKey: uuid.New(), UsernameKey: "bob"})
+	like, love := model.ReactionLike, model.ReactionLove
+
+	steps := []struct {
+		name   string
+		ctx    context.Context
+		react  model.Reaction
+		mine   *model.Reaction
+		counts map[model.Reaction]int
+	}{
+		{"alice likes", alice, like, &like, map[model.Reaction]int{like: 1}},
+		{"bob likes", bob, like, &like, map[model.Reaction]int{like: 2}},
+		{"alice switches to love", alice, love, &love, map[model.Reaction]int{like: 1, love: 1}},
+		{"alice sends love again to remove it", alice, love, nil, map[model.Reaction]int{like: 1}},
+		{"bob sends like again to remove it", bob, like, nil, map[model.Reaction]int{}},
+	}
+	for _, step := range steps {
+		res, err := s.toggle(step.ctx, nil, model.ReactionTargetPost, targetID, step.react)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if !reflect.DeepEqual(res.MyReaction, step.mine) || !reflect.DeepEqual(res.ReactionCounts, step.counts) {
+			t.Errorf("%s: my reaction %v, counts %v; want %v, %v", step.name, res.MyReaction, res.ReactionCounts, step.mine, step.counts)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+)
+
+// RetentionJob permanently removes items and posts that have been sitting
+// in the trash for longer than the retention period.
+type RetentionJob struct {
+	items     ItemServiceImpl
+	posts     PostServiceImpl
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewRetentionJob(items ItemServiceImpl, posts PostServiceImpl, retention time.Duration) *RetentionJob {
+	return &RetentionJob{
+		items:     items,
+		posts:     posts,
+		retention: retention,
+		interval:  time.Hour,
+	}
+}
+
+// Run purges once immediately and then on every interval until ctx is done.
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		j.purge(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *RetentionJob) purge(ctx context.Context) {
+	before := time.Now().Add(-j.retention)
+	items, err := j.items.PurgeDeletedItemsService(ctx, before)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "retention: failed to purge items: ")
+	}
+	posts, err := j.posts.PurgeDeletedPostsService(ctx, before)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "retention: failed to purge posts: ")
+	}
+	if items > 0 || posts > 0 {
+		helper.SuccessMsgCtx(ctx, fmt.Sprintf("retention: purged %d items and %d posts", items, posts))
+	}
+}
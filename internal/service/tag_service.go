@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxTrendingWindow bounds how far back trending tags look.
+const maxTrendingWindow = 7 * 24 * time.Hour
+
+type TagServiceImpl interface {
+	GetPostsByTagService(ctx context.Context, page *model.TagPostsPageReq) (*model.PostsPageRes, error)
+	GetTrendingTagsService(ctx context.Context, window time.Duration, limit int) ([]model.TrendingTag, error)
+}
+type TagService struct {
+	repo     repository.TagRepoImpl
+	postRepo repository.PostRepoImpl
+	db       *pgxpool.Pool
+}
+
+func NewTagService(repo repository.TagRepoImpl, postRepo repository.PostRepoImpl, db *pgxpool.Pool) TagServiceImpl {
+	return &TagService{
+		repo:     repo,
+		postRepo: postRepo,
+		db:       db,
+	}
+}
+
+func (s *TagService) GetPostsByTagService(ctx context.Context, page *model.TagPostsPageReq) (*model.PostsPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	page.Tag = model.NormalizeTag(page.Tag)
+	if page.Tag == "" {
+		return nil, model.Invalid("invalid tag")
+	}
+	if page.Limit <= 0 {
+		page.Limit = 10
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetPostsByTagRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get tagged posts: ")
+		return nil, err
+	}
+	posts := make([]*model.Post, len(res.Posts))
+	for i := range res.Posts {
+		posts[i] = &res.Posts[i]
+	}
+	if err := loadPostItems(ctx, tx, s.postRepo, posts...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+func (s *TagService) GetTrendingTagsService(ctx context.Context, window time.Duration, limit int) ([]model.TrendingTag, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if window <= 0 {
+		window = 24 * time.Hour
+	}
+	if window > maxTrendingWindow {
+		window = maxTrendingWindow
+	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	tags, err := s.repo.GetTrendingTagsRepo(ctx, tx, &model.TrendingTagsReq{
+		Since: time.Now().Add(-window),
+		Limit: limit,
+	})
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get trending tags: ")
+		return nil, err
+	}
+	return tags, nil
+}
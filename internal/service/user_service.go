@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/metrics"
 	"github.com/bagasadiii/buy-n-con/internal/middleware"
 	"github.com/bagasadiii/buy-n-con/internal/model"
 	"github.com/bagasadiii/buy-n-con/internal/repository"
@@ -11,51 +12,57 @@ import (
 )
 
 type UserServiceImpl interface {
-	RegisterService(ctx context.Context, new *model.RegisterInput)(*model.User, error)
-	LoginService(ctx context.Context, new *model.LoginInput)(string, error)
-	GetUserService(ctx context.Context, username string)(*model.UserResponse, error)
+	RegisterService(ctx context.Context, new *model.RegisterInput) (*model.User, error)
+	LoginService(ctx context.Context, new *model.LoginInput) (string, error)
+	GetUserService(ctx context.Context, username string) (*model.UserResponse, error)
 }
 type UserService struct {
-	repo repository.UserRepoImpl
+	repo   repository.UserRepoImpl
+	tokens *middleware.TokenAuth
 }
-func NewUserService(repo repository.UserRepoImpl)UserServiceImpl{
-	return &UserService{repo:repo}
+
+func NewUserService(repo repository.UserRepoImpl, tokens *middleware.TokenAuth) UserServiceImpl {
+	return &UserService{repo: repo, tokens: tokens}
 }
-func(s *UserService)RegisterService(ctx context.Context, new *model.RegisterInput)(*model.User, error){
+func (s *UserService) RegisterService(ctx context.Context, new *model.RegisterInput) (*model.User, error) {
 	user, err := model.NewUser(new)
 	if err != nil {
-		helper.ErrMsg(err, "failed to create user: ")
+		helper.ErrMsgCtx(ctx, err, "failed to create user: ")
 		return nil, err
 	}
 	if err := s.repo.RegisterRepo(ctx, user); err != nil {
-		helper.ErrMsg(err, "failed to create user (database error): ")
+		helper.ErrMsgCtx(ctx, err, "failed to create user (database error): ")
 		return nil, err
 	}
-	helper.SuccessMsg("user created")
+	helper.SuccessMsgCtx(ctx, "user created")
+	metrics.Registrations.Inc()
 	return user, nil
 }
-func(s *UserService)LoginService(ctx context.Context, new *model.LoginInput)(string, error){
+func (s *UserService) LoginService(ctx context.Context, new *model.LoginInput) (string, error) {
 	user, err := s.repo.LoginRepo(ctx, new)
 	if err != nil {
-		helper.ErrMsg(err, "failed: ")
+		helper.ErrMsgCtx(ctx, err, "failed: ")
+		metrics.Logins.WithLabelValues("failure").Inc()
 		return "", err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(new.Password)); err != nil {
-		helper.ErrMsg(err, "invalid password: ")
-		return "", err
+		helper.ErrMsgCtx(ctx, err, "invalid password: ")
+		metrics.Logins.WithLabelValues("failure").Inc()
+		return "", model.ErrInvalidCredentials.Wrap(err)
 	}
-	token, err := middleware.GenerateToken(user.UserID, new.Username)
+	token, err := s.tokens.GenerateToken(user.UserID, new.Username)
 	if err != nil {
-		helper.ErrMsg(err, "failed to create token: ")
+		helper.ErrMsgCtx(ctx, err, "failed to create token: ")
 		return "", err
 	}
+	metrics.Logins.WithLabelValues("success").Inc()
 	return token, nil
 }
-func(s *UserService)GetUserService(ctx context.Context, username string)(*model.UserResponse, error){
+func (s *UserService) GetUserService(ctx context.Context, username string) (*model.UserResponse, error) {
 	user, err := s.repo.GetUserRepo(ctx, username)
 	if err != nil {
-		helper.ErrMsg(err, "failed to get user: ")
+		helper.ErrMsgCtx(ctx, err, "failed to get user: ")
 		return nil, err
 	}
 	return user, nil
-}
\ No newline at end of file
+}
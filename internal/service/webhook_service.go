@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WebhookDispatcher is what other services use to fire a webhook event for a
+// user. Like NotifyTx it joins the caller's transaction, so deliveries are
+// only queued for changes that commit.
+type WebhookDispatcher interface {
+	DispatchTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event model.WebhookEvent, data any) error
+}
+
+type WebhookServiceImpl interface {
+	WebhookDispatcher
+	CreateWebhookService(ctx context.Context, userID uuid.UUID, input *model.WebhookInput) (*model.Webhook, error)
+	GetWebhooksService(ctx context.Context, userID uuid.UUID) ([]model.Webhook, error)
+	DeleteWebhookService(ctx context.Context, userID, webhookID uuid.UUID) error
+	GetDeliveriesService(ctx context.Context, page *model.WebhookDeliveriesPageReq) (*model.WebhookDeliveriesPageRes, error)
+	RedeliverService(ctx context.Context, userID, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+}
+type WebhookService struct {
+	repo     repository.WebhookRepoImpl
+	db       helper.TxBeginner
+	resolver *net.Resolver
+}
+
+func NewWebhookService(repo repository.WebhookRepoImpl, db *pgxpool.Pool) WebhookServiceImpl {
+	return &WebhookService{
+		repo:     repo,
+		db:       db,
+		resolver: net.DefaultResolver,
+	}
+}
+
+// checkWebhookURL refuses URLs the worker would not be allowed to call, so
+// a bad webhook fails when it is created rather than on every delivery. The
+// worker checks again when it connects, since DNS can change in between.
+func checkWebhookURL(ctx context.Context, resolver *net.Resolver, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return model.ErrWebhookURL
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil {
+		if !publicAddr(ip) {
+			return model.ErrWebhookURL
+		}
+		return nil
+	}
+	ips, err := resolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return model.ErrWebhookURL.Wrap(err)
+	}
+	for _, ip := range ips {
+		if !publicAddr(ip) {
+			return model.ErrWebhookURL
+		}
+	}
+	return nil
+}
+
+// DispatchTx queues one delivery per webhook of userID subscribed to event.
+func (s *WebhookService) DispatchTx(ctx context.Context, tx pgx.Tx, userID uuid.UUID, event model.WebhookEvent, data any) error {
+	webhookIDs, err := s.repo.GetSubscribedWebhooksRepo(ctx, tx, userID, event)
+	if err != nil || len(webhookIDs) == 0 {
+		return err
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	payload := &model.WebhookPayload{
+		EventID:   uuid.New(),
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      raw,
+	}
+	for _, webhookID := range webhookIDs {
+		delivery, err := model.NewWebhookDelivery(webhookID, payload)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.CreateDeliveryRepo(ctx, tx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (s *WebhookService) CreateWebhookService(ctx context.Context, userID uuid.UUID, input *model.WebhookInput) (*model.Webhook, error) {
+	if err := checkWebhookURL(ctx, s.resolver, input.URL); err != nil {
+		return nil, err
+	}
+	webhook, err := model.NewWebhook(userID, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if err := s.repo.CreateWebhookRepo(ctx, tx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+func (s *WebhookService) GetWebhooksService(ctx context.Context, userID uuid.UUID) ([]model.Webhook, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	return s.repo.GetWebhooksRepo(ctx, tx, userID)
+}
+func (s *WebhookService) DeleteWebhookService(ctx context.Context, userID, webhookID uuid.UUID) error {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	return s.repo.DeleteWebhookRepo(ctx, tx, userID, webhookID)
+}
+func (s *WebhookService) GetDeliveriesService(ctx context.Context, page *model.WebhookDeliveriesPageReq) (*model.WebhookDeliveriesPageRes, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if page.Limit <= 0 {
+		page.Limit = 20
+	}
+	if page.Offset < 0 {
+		page.Offset = 0
+	}
+	res, err := s.repo.GetDeliveriesRepo(ctx, tx, page)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get webhook deliveries: ")
+		return nil, err
+	}
+	return res, nil
+}
+
+// RedeliverService queues a new delivery of the same payload, leaving the
+// original in the log as it was.
+func (s *WebhookService) RedeliverService(ctx context.Context, userID, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	ctx, tx, err := helper.BeginTx(ctx, s.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to begin transaction: ")
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	original, err := s.repo.GetDeliveryRepo(ctx, tx, userID, webhookID, deliveryID)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "failed to get webhook delivery: ")
+		return nil, err
+	}
+	var payload model.WebhookPayload
+	if err := json.Unmarshal(original.Payload, &payload); err != nil {
+		return nil, err
+	}
+	delivery, err := model.NewWebhookDelivery(original.WebhookID, &payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateDeliveryRepo(ctx, tx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/google/uuid"
+)
+
+func TestCheckWebhookURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://93.184.216.34/hooks":        true,
+		"http://[2606:4700::1111]:8080/hook": true,
+		"ftp://93.184.216.34/hooks":          false,
+		"https:///hooks":                     false,
+		"http://127.0.0.1:8080/":             false,
+		"http://localhost/":                  false,
+		"http://[::1]/":                      false,
+		"http://169.254.169.254/latest/":     false,
+		"http://10.0.0.5/":                   false,
+		"http://192.168.0.10/":               false,
+	}
+	for raw, ok := range tests {
+		err := checkWebhookURL(context.Background(), net.DefaultResolver, raw)
+		if ok && err != nil {
+			t.Errorf("%s refused: %v", raw, err)
+		}
+		if !ok && !errors.Is(err, model.ErrValidation) {
+			t.Errorf("%s: err = %v, want a validation error", raw, err)
+		}
+	}
+}
+
+func TestRedeliverService(t *testing.T) {
+	original := newTestDelivery(t)
+	original.Status = model.DeliveryFailed
+	original.Attempts = webhookMaxAttempts
+	original.NextAttemptAt = nil
+	repo := &fakeWebhookRepo{deliveries: []*model.WebhookDelivery{original}}
+	service := &WebhookService{repo: repo, db: fakeDB{}}
+
+	delivery, err := service.RedeliverService(context.Background(), uuid.New(), original.WebhookID, original.DeliveryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.DeliveryID == original.DeliveryID || delivery.WebhookID != original.WebhookID {
+		t.Errorf("redelivery %s of webhook %s, want a new delivery of %s", delivery.DeliveryID, delivery.WebhookID, original.WebhookID)
+	}
+	if delivery.Status != model.DeliveryPending || delivery.Attempts != 0 || delivery.NextAttemptAt == nil {
+		t.Errorf("redelivery = %+v, want pending and due", delivery)
+	}
+	var sent, resent model.WebhookPayload
+	json.Unmarshal(original.Payload, &sent)
+	json.Unmarshal(delivery.Payload, &resent)
+	if resent.EventID != sent.EventID || string(resent.Data) != string(sent.Data) {
+		t.Errorf("payload = %s, want the original %s", delivery.Payload, original.Payload)
+	}
+	if len(repo.deliveries) != 2 || original.Status != model.DeliveryFailed || original.Attempts != webhookMaxAttempts {
+		t.Errorf("original delivery changed or redelivery not queued: %d deliveries, original %+v", len(repo.deliveries), original)
+	}
+
+	if _, err := service.RedeliverService(context.Background(), uuid.New(), uuid.New(), original.DeliveryID); !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("redeliver of another webhook's delivery: err = %v, want not found", err)
+	}
+}
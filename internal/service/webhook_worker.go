@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/helper"
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var errWebhookAddr = errors.New("webhook address is not public")
+
+const (
+	webhookTimeout     = 10 * time.Second
+	webhookLease       = time.Minute
+	webhookBatch       = 20
+	webhookMaxAttempts = 10
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+	maxWebhookError    = 500
+)
+
+// WebhookWorker sends queued webhook deliveries. Several instances can run at
+// once; each claims its own batch.
+type WebhookWorker struct {
+	repo     repository.WebhookRepoImpl
+	db       helper.TxBeginner
+	client   *http.Client
+	interval time.Duration
+}
+
+func NewWebhookWorker(repo repository.WebhookRepoImpl, db *pgxpool.Pool) *WebhookWorker {
+	return &WebhookWorker{
+		repo:     repo,
+		db:       db,
+		client:   newWebhookClient(),
+		interval: 5 * time.Second,
+	}
+}
+
+// newWebhookClient returns a client that only connects to public addresses.
+// The check runs on the address actually dialed, after DNS, so a host that
+// resolves or redirects to an internal address is refused as well.
+// Proxies are not used, since they would dial on the worker's behalf.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !publicAddr(ip) {
+				return fmt.Errorf("%w: %s", errWebhookAddr, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// sharedAddressSpace is 100.64.0.0/10, the carrier-grade NAT range.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether ip is a unicast address on the internet, not
+// loopback, private (RFC 1918 and fc00::/7), link-local such as the
+// 169.254.169.254 metadata service, or otherwise special.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// Run sends due deliveries on every interval until ctx is done.
+func (w *WebhookWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.sendDue(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *WebhookWorker) sendDue(ctx context.Context) {
+	deliveries, err := w.claim(ctx)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "webhooks: failed to claim deliveries: ")
+		return
+	}
+	// Claimed deliveries finish even if ctx ends meanwhile, so a shutdown
+	// does not record a canceled request as a failed attempt. Each one is
+	// bounded by webhookTimeout.
+	ctx = context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+			w.attempt(ctx, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+}
+
+func (w *WebhookWorker) claim(ctx context.Context) ([]model.WebhookDelivery, error) {
+	ctx, tx, err := helper.BeginTx(ctx, w.db)
+	if err != nil {
+		return nil, err
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	return w.repo.ClaimDueDeliveriesRepo(ctx, tx, webhookBatch, time.Now().Add(webhookLease))
+}
+
+// attempt sends one delivery and records the outcome. Anything but a 2xx is
+// retried with exponential backoff until webhookMaxAttempts.
+func (w *WebhookWorker) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	statusCode, sendErr := w.send(ctx, delivery)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = nil
+	delivery.LastError = nil
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+	switch {
+	case sendErr == nil:
+		delivery.Status = model.DeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= webhookMaxAttempts:
+		delivery.Status = model.DeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(webhookBackoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+	if sendErr != nil {
+		msg := sendErr.Error()
+		if len(msg) > maxWebhookError {
+			msg = msg[:maxWebhookError]
+		}
+		delivery.LastError = &msg
+	}
+
+	ctx, tx, err := helper.BeginTx(ctx, w.db)
+	if err != nil {
+		helper.ErrMsgCtx(ctx, err, "webhooks: failed to begin transaction: ")
+		return
+	}
+	defer helper.CommitOrRollback(ctx, tx)
+	if err := w.repo.RecordAttemptRepo(ctx, tx, delivery); err != nil {
+		helper.ErrMsgCtx(ctx, err, "webhooks: failed to record attempt: ")
+	}
+}
+
+func (w *WebhookWorker) send(ctx context.Context, delivery *model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "buy-n-con-webhooks")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", delivery.DeliveryID.String())
+	req.Header.Set("X-Webhook-Signature", model.SignWebhook(delivery.Secret, time.Now(), delivery.Payload))
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff doubles the wait after every failed attempt: 30s, 1m, 2m,
+// and so on up to webhookMaxBackoff.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
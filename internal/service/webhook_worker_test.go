@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bagasadiii/buy-n-con/internal/model"
+	"github.com/bagasadiii/buy-n-con/internal/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeDB hands out transactions that only remember how they ended.
+type fakeDB struct{}
+
+func (fakeDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{}, nil
+}
+
+type fakeTx struct {
+	pgx.Tx
+}
+
+func (*fakeTx) Commit(ctx context.Context) error   { return nil }
+func (*fakeTx) Rollback(ctx context.Context) error { return nil }
+
+// fakeWebhookRepo keeps deliveries in memory and claims them the way
+// ClaimDueDeliveriesRepo does: pending and due, pushed back to the lease.
+type fakeWebhookRepo struct {
+	repository.WebhookRepoImpl
+	mu         sync.Mutex
+	url        string
+	secret     string
+	deliveries []*model.WebhookDelivery
+	leases     []time.Time
+}
+
+func (r *fakeWebhookRepo) ClaimDueDeliveriesRepo(ctx context.Context, tx pgx.Tx, limit int, leaseUntil time.Time) ([]model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.leases = append(r.leases, leaseUntil)
+	var claimed []model.WebhookDelivery
+	for _, d := range r.deliveries {
+		if len(claimed) == limit {
+			break
+		}
+		if d.Status != model.DeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		d.NextAttemptAt = &leaseUntil
+		delivery := *d
+		delivery.URL, delivery.Secret = r.url, r.secret
+		claimed = append(claimed, delivery)
+	}
+	return claimed, nil
+}
+
+func (r *fakeWebhookRepo) RecordAttemptRepo(ctx context.Context, tx pgx.Tx, delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, d := range r.deliveries {
+		if d.DeliveryID == delivery.DeliveryID {
+			*d = *delivery
+			d.URL, d.Secret = "", ""
+		}
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepo) GetDeliveryRepo(ctx context.Context, tx pgx.Tx, userID, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	for _, d := range r.deliveries {
+		if d.WebhookID == webhookID && d.DeliveryID == deliveryID {
+			delivery := *d
+			return &delivery, nil
+		}
+	}
+	return nil, model.NotFound("webhook delivery not found")
+}
+
+func (r *fakeWebhookRepo) CreateDeliveryRepo(ctx context.Context, tx pgx.Tx, delivery *model.WebhookDelivery) error {
+	r.deliveries = append(r.deliveries, delivery)
+	return nil
+}
+
+func newTestDelivery(t *testing.T) *model.WebhookDelivery {
+	t.Helper()
+	delivery, err := model.NewWebhookDelivery(uuid.New(), &model.WebhookPayload{
+		EventID:   uuid.New(),
+		Event:     model.WebhookItemSoldOut,
+		CreatedAt: time.Now(),
+		Data:      json.RawMessage(`{"name":"Lamp"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return delivery
+}
+
+// newTestWorker returns a worker sending to receiver. The receiver listens
+// on loopback, so the worker uses its client instead of the guarded one.
+func newTestWorker(receiver *httptest.Server, deliveries ...*model.WebhookDelivery) (*WebhookWorker, *fakeWebhookRepo) {
+	repo := &fakeWebhookRepo{url: receiver.URL, secret: "secret", deliveries: deliveries}
+	return &WebhookWorker{repo: repo, db: fakeDB{}, client: receiver.Client()}, repo
+}
+
+func TestWebhookWorkerSendsSignedDelivery(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Header, body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	delivery := newTestDelivery(t)
+	worker, _ := newTestWorker(receiver, delivery)
+
+	worker.sendDue(context.Background())
+
+	req := <-requests
+	if string(req.body) != string(delivery.Payload) {
+		t.Errorf("body = %s, want %s", req.body, delivery.Payload)
+	}
+	if got := req.header.Get("X-Webhook-Event"); got != string(model.WebhookItemSoldOut) {
+		t.Errorf("X-Webhook-Event = %s", got)
+	}
+	if got := req.header.Get("X-Webhook-Delivery"); got != delivery.DeliveryID.String() {
+		t.Errorf("X-Webhook-Delivery = %s, want %s", got, delivery.DeliveryID)
+	}
+	if err := model.VerifyWebhook("secret", req.header.Get("X-Webhook-Signature"), req.body, time.Now(), time.Minute); err != nil {
+		t.Errorf("signature: %v", err)
+	}
+	if delivery.Status != model.DeliverySucceeded || delivery.Attempts != 1 || delivery.DeliveredAt == nil || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want succeeded after 1 attempt", delivery)
+	}
+	if delivery.LastStatusCode == nil || *delivery.LastStatusCode != http.StatusNoContent {
+		t.Errorf("last status code = %v, want 204", delivery.LastStatusCode)
+	}
+}
+
+func TestWebhookWorkerRetriesFailures(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	delivery := newTestDelivery(t)
+	worker, _ := newTestWorker(receiver, delivery)
+
+	before := time.Now()
+	worker.sendDue(context.Background())
+	if delivery.Status != model.DeliveryPending || delivery.Attempts != 1 {
+		t.Fatalf("delivery = %+v, want pending after 1 attempt", delivery)
+	}
+	if delivery.LastStatusCode == nil || *delivery.LastStatusCode != http.StatusInternalServerError || delivery.LastError == nil {
+		t.Errorf("last status %v, error %v, want 500 and an error", delivery.LastStatusCode, delivery.LastError)
+	}
+	if next := delivery.NextAttemptAt; next == nil || next.Before(before.Add(webhookBaseBackoff)) || next.After(time.Now().Add(webhookBaseBackoff)) {
+		t.Errorf("next attempt at %v, want %v after the attempt", next, webhookBaseBackoff)
+	}
+
+	// The last allowed attempt gives up instead of scheduling another.
+	past := time.Now().Add(-time.Second)
+	delivery.Attempts = webhookMaxAttempts - 1
+	delivery.NextAttemptAt = &past
+	worker.sendDue(context.Background())
+	if delivery.Status != model.DeliveryFailed || delivery.Attempts != webhookMaxAttempts || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want failed after %d attempts", delivery, webhookMaxAttempts)
+	}
+}
+
+func TestWebhookWorkerLeasesClaims(t *testing.T) {
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	defer receiver.Close()
+	delivery := newTestDelivery(t)
+	worker, repo := newTestWorker(receiver, delivery)
+
+	before := time.Now()
+	claimed, err := worker.claim(context.Background())
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("claim = %d deliveries, %v; want 1", len(claimed), err)
+	}
+	lease := repo.leases[0]
+	if lease.Before(before.Add(webhookLease)) || lease.After(time.Now().Add(webhookLease)) {
+		t.Errorf("lease until %v, want %v from the claim", lease, webhookLease)
+	}
+	// A worker that dies mid-send leaves the claim to expire; until then
+	// nobody else picks the delivery up.
+	if again, _ := worker.claim(context.Background()); len(again) != 0 {
+		t.Errorf("claimed %d leased deliveries again", len(again))
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, webhookMaxBackoff},
+		{40, webhookMaxBackoff},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	called := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer receiver.Close()
+
+	_, err := newWebhookClient().Post(receiver.URL, "application/json", nil)
+	if !errors.Is(err, errWebhookAddr) {
+		t.Errorf("POST to %s: err = %v, want %v", receiver.URL, err, errWebhookAddr)
+	}
+	if called {
+		t.Error("the loopback receiver was called")
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+		"127.0.0.1":       false,
+		"::1":             false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::ffff:10.0.0.1": false,
+		"224.0.0.1":       false,
+	}
+	for addr, want := range tests {
+		if got := publicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("publicAddr(%s) = %t, want %t", addr, got, want)
+		}
+	}
+}
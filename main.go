@@ -1,54 +1,202 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/bagasadiii/buy-n-con/app"
 	"github.com/bagasadiii/buy-n-con/handler"
+	"github.com/bagasadiii/buy-n-con/helper"
 	"github.com/bagasadiii/buy-n-con/internal/config"
+	"github.com/bagasadiii/buy-n-con/internal/metrics"
+	"github.com/bagasadiii/buy-n-con/internal/middleware"
+	"github.com/bagasadiii/buy-n-con/internal/migrations"
+	"github.com/bagasadiii/buy-n-con/internal/realtime"
 	"github.com/bagasadiii/buy-n-con/internal/repository"
 	"github.com/bagasadiii/buy-n-con/internal/service"
-	"github.com/joho/godotenv"
-	"github.com/rs/cors"
+	"github.com/bagasadiii/buy-n-con/internal/telemetry"
 )
 
 func main() {
-	err := godotenv.Load(".env")
+	// migrate only needs the database, so it runs before the server's
+	// settings are required.
+	dbCfg, args, err := config.LoadDB(os.Args[1:])
+	if len(args) > 0 && args[0] == "migrate" {
+		if err != nil {
+			log.Fatalf("invalid configuration:\n%v", err)
+		}
+		db := config.DBConnection(*dbCfg, nil)
+		defer db.Close()
+		if err := runMigrate(context.Background(), db, args[1:]); err != nil {
+			log.Fatal("migrate: ", err)
+		}
+		return
+	}
+
+	cfg, _, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	if err := helper.ConfigureLogger(cfg.Log); err != nil {
+		log.Fatal("failed to configure logging: ", err)
+	}
+	log.SetFlags(0)
+	log.SetOutput(helper.LogWriter())
+	log.Printf("config: %s", cfg)
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatal("failed to get .env file: ", err)
+		log.Fatal("failed to set up tracing: ", err)
 	}
-	db := config.DBConnection()
+
+	db := config.DBConnection(cfg.DB, telemetry.QueryTracer{})
 	defer db.Close()
+	metrics.RegisterPool(db)
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatal("failed to load migrations: ", err)
+	}
+	if cfg.AutoMigrate {
+		if err := autoMigrate(context.Background(), migrator); err != nil {
+			log.Fatal("failed to migrate database: ", err)
+		}
+	}
+
+	tokens := middleware.NewTokenAuth(cfg.Token)
+
+	// Background workers share one context so shutdown can stop them all and
+	// wait for them before the pool closes.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	hub := realtime.NewHub()
+	publisher := realtime.NewPublisher()
+	runWorker(func(ctx context.Context) { realtime.Listen(ctx, db, hub) })
+	tickets := realtime.NewTickets(db)
+	realtimeHand := handler.NewRealtimeHandler(hub, tokens, tickets, cfg.AllowedOrigins)
+	eventLog := realtime.NewEventLog(db, cfg.EventRetention)
+	runWorker(eventLog.RunPruner)
+	eventsHand := handler.NewEventsHandler(hub, eventLog, tokens, tickets)
+
+	notifRepo := repository.NewNotificationRepository()
+	notifServ := service.NewNotificationService(notifRepo, publisher, db)
+	notifHand := handler.NewNotificationHandler(notifServ)
 
 	userRepo := repository.NewUserRepository(db)
-	userServ := service.NewUserService(userRepo)
-	userHand := handler.NewUserHandler(userServ)
+	userServ := service.NewUserService(userRepo, tokens)
+	userHand := handler.NewUserHandler(userServ, tokens)
+
+	webhookRepo := repository.NewWebhookRepository()
+	webhookServ := service.NewWebhookService(webhookRepo, db)
+	webhookHand := handler.NewWebhookHandler(webhookServ)
+	runWorker(service.NewWebhookWorker(webhookRepo, db).Run)
 
 	itemRepo := repository.NewItemRepository()
-	itemServ := service.NewItemService(itemRepo, db)
+	itemServ := service.NewItemService(itemRepo, notifServ, webhookServ, db)
 	itemHand := handler.NewItemHandler(itemServ)
 
 	postRepo := repository.NewPostRepository()
-	postServ := service.NewServiceImpl(postRepo, db)
+	tagRepo := repository.NewTagRepository()
+	postServ := service.NewServiceImpl(postRepo, tagRepo, notifServ, db)
 	postHand := handler.NewPostHandler(postServ)
 
+	commentRepo := repository.NewCommentRepository()
+	commentServ := service.NewCommentService(commentRepo, postRepo, notifServ, db)
+	commentHand := handler.NewCommentHandler(commentServ)
+
+	reactionRepo := repository.NewReactionRepository()
+	reactionServ := service.NewReactionService(reactionRepo, postRepo, itemRepo, db)
+	reactionHand := handler.NewReactionHandler(reactionServ)
+
+	followRepo := repository.NewFollowRepository()
+	followServ := service.NewFollowService(followRepo, notifServ, db)
+	followHand := handler.NewFollowHandler(followServ)
+
+	feedServ := service.NewFeedService(postRepo, itemRepo, db)
+	feedHand := handler.NewFeedHandler(feedServ)
+
+	tagServ := service.NewTagService(tagRepo, postRepo, db)
+	tagHand := handler.NewTagHandler(tagServ)
+
+	messageRepo := repository.NewMessageRepository()
+	messageServ := service.NewMessageService(messageRepo, publisher, db)
+	messageHand := handler.NewMessageHandler(messageServ)
+
+	retention := service.NewRetentionJob(itemServ, postServ, cfg.TrashRetention)
+	runWorker(retention.Run)
+
+	healthHand := handler.NewHealthHandler(
+		handler.HealthCheck{Name: "database", Check: db.Ping},
+		handler.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			pending, err := migrator.Pending(ctx)
+			if err != nil {
+				return err
+			}
+			if len(pending) > 0 {
+				return fmt.Errorf("%d pending, next is %d_%s", len(pending), pending[0].Version, pending[0].Name)
+			}
+			return nil
+		}},
+		handler.HealthCheck{Name: "realtime", Check: func(ctx context.Context) error {
+			if !hub.Listening() {
+				return errors.New("event listener is not connected")
+			}
+			return nil
+		}},
+	)
+
 	route := app.Routes{
-		User: userHand,
-		Item: itemHand,
-		Post: postHand,
+		User:         userHand,
+		Item:         itemHand,
+		Post:         postHand,
+		Comment:      commentHand,
+		Reaction:     reactionHand,
+		Follow:       followHand,
+		Feed:         feedHand,
+		Tag:          tagHand,
+		Message:      messageHand,
+		Realtime:     realtimeHand,
+		Notification: notifHand,
+		Events:       eventsHand,
+		Webhook:      webhookHand,
+		Health:       healthHand,
 	}
 
-	r := app.SetupRouter(&route)
+	mux, err := app.SetupRouter(&route, tokens, cfg)
+	if err != nil {
+		log.Fatal("failed to set up routes: ", err)
+	}
 
-	c := cors.New(cors.Options{
-		AllowedOrigins: []string{"http://localhost:5173"},
-		AllowCredentials: true,
-		Debug: true,
-	})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	srv := newServer(cfg, mux)
+	srv.RegisterOnShutdown(hub.Close)
+	err = serve(ctx, srv, cfg.HTTP)
 
-	if err := http.ListenAndServe(":8080", c.Handler(r)); err != nil {
+	stopWorkers()
+	workers.Wait()
+	db.Close()
+	flushCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	if err := shutdownTracing(flushCtx); err != nil {
+		log.Printf("failed to flush traces: %v", err)
+	}
+	cancel()
+	if err != nil {
 		log.Fatalf("failed to run server: %v\n", err)
 	}
-	log.Println("server running")
-}
\ No newline at end of file
+	log.Println("server stopped")
+}